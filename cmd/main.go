@@ -1,8 +1,17 @@
 package main
 
 import (
-	"github.com/sebastianreh/compass-code-assessment/internal"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"github.com/sebastianreh/compass-code-assessment/internal"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -10,18 +19,62 @@ const (
 )
 
 func main() {
+	// Exit once run returned, so every deferred cleanup ran
+	if err := run(); err != nil {
+		logrus.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	// Parse command line arguments
+	options, err := internal.ParseOptions(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Cancel the processing on Ctrl-C or SIGTERM, leaving the previous output files untouched
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Build dependencies
-	build := internal.Build()
+	build, err := internal.Build(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := build.Close(); err != nil {
+			build.Logger.Errorf("error closing database: %v", err)
+		}
+	}()
+
+	err = options.PrepareOutputs()
+	if err != nil {
+		return fmt.Errorf("error preparing output files: %w", err)
+	}
 
 	// Generate start timestamp
 	start := time.Now()
 	build.Logger.Infof("Start processing at %v", start.Format(timeFormat))
-	_, err := build.Service.Evaluate()
+	_, err = build.Service.Evaluate(ctx)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("processing stopped after %v: %w", time.Since(start), err)
+	}
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	// Log finish time
 	build.Logger.Infof("Finish processing at %v", time.Now().Format(timeFormat))
 	build.Logger.Infof("Process took %v", time.Since(start))
+	return nil
 }
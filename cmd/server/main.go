@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sebastianreh/compass-code-assessment/internal"
+	"github.com/sebastianreh/compass-code-assessment/internal/server"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	// Exit once run returned, so every deferred cleanup ran
+	if err := run(); err != nil {
+		logrus.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	// Parse command line arguments
+	options, err := internal.ParseServerOptions(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Stop the server on Ctrl-C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Build dependencies
+	build, err := internal.Build(ctx, options.Options)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := build.Close(); err != nil {
+			build.Logger.Errorf("error closing database: %v", err)
+		}
+	}()
+
+	httpServer := &http.Server{
+		Addr:    options.Addr,
+		Handler: server.NewHandler(build.Logger, build.Service, build.Config, build.Columns),
+	}
+
+	// ListenAndServe returns as soon as the shutdown starts, wait for the requests in flight to finish
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		build.Logger.Infof("Shutting down the server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), options.ShutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			build.Logger.Errorf("Error shutting down the server: %v", err)
+		}
+	}()
+
+	build.Logger.Infof("Listening on %v", options.Addr)
+	err = httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving: %w", err)
+	}
+	<-stopped
+	return nil
+}
@@ -0,0 +1,200 @@
+package contact
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Address holds the components of a postal address, normalized to lower case with standard abbreviations expanded
+type Address struct {
+	HouseNumber string `json:"house_number"`
+	StreetName  string `json:"street_name"`
+	StreetType  string `json:"street_type"`
+	Unit        string `json:"unit"`
+	POBox       string `json:"po_box"`
+}
+
+var streetTypes = map[string]string{
+	"alley": "alley", "aly": "alley",
+	"avenue": "avenue", "ave": "avenue", "av": "avenue", "avn": "avenue",
+	"boulevard": "boulevard", "blvd": "boulevard",
+	"circle": "circle", "cir": "circle",
+	"court": "court", "ct": "court",
+	"drive": "drive", "dr": "drive",
+	"highway": "highway", "hwy": "highway",
+	"lane": "lane", "ln": "lane",
+	"parkway": "parkway", "pkwy": "parkway",
+	"place": "place", "pl": "place",
+	"road": "road", "rd": "road",
+	"square": "square", "sq": "square",
+	"street": "street", "st": "street", "str": "street",
+	"terrace": "terrace", "ter": "terrace",
+	"way": "way",
+}
+
+var directionals = map[string]string{
+	"n": "north", "s": "south", "e": "east", "w": "west",
+	"ne": "northeast", "nw": "northwest", "se": "southeast", "sw": "southwest",
+}
+
+var unitDesignators = map[string]bool{
+	"ap": true, "apt": true, "apartment": true, "suite": true, "ste": true, "unit": true, "#": true,
+}
+
+// Share of the address credit given by each matching component
+const (
+	houseNumberWeight = 0.35
+	streetNameWeight  = 0.35
+	streetTypeWeight  = 0.1
+	unitWeight        = 0.1
+	poBoxWeight       = 0.1
+)
+
+// ParseAddress splits a free text address like "P.O. Box 775, 8910 Arcu. Road" into its components
+func ParseAddress(value string) Address {
+	tokens := addressTokens(value)
+	var address Address
+
+	tokens, address.POBox = extractPOBox(tokens)
+	tokens, address.Unit = extractUnit(tokens)
+
+	if len(tokens) > 0 && strings.ContainsFunc(tokens[0], unicode.IsDigit) {
+		address.HouseNumber = tokens[0]
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) > 0 {
+		if streetType, ok := streetTypes[tokens[len(tokens)-1]]; ok {
+			address.StreetType = streetType
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+
+	for i, token := range tokens {
+		if direction, ok := directionals[token]; ok {
+			tokens[i] = direction
+		}
+	}
+	address.StreetName = strings.Join(tokens, " ")
+
+	return address
+}
+
+// NormalizeAddress returns the address with standard abbreviations expanded, e.g. "449-6990 Tellus. Rd." -> "449-6990 tellus road"
+func NormalizeAddress(value string) string {
+	return ParseAddress(value).String()
+}
+
+func (a Address) String() string {
+	var parts []string
+	if a.POBox != "" {
+		parts = append(parts, "po box "+a.POBox)
+	}
+	if a.Unit != "" {
+		parts = append(parts, "unit "+a.Unit)
+	}
+	for _, part := range []string{a.HouseNumber, a.StreetName, a.StreetType} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// componentSimilarity compares the components present in either address, weighting each one by its share of the credit.
+// Addresses on different streets are never similar, however many other components they share.
+func (a Address) componentSimilarity(other Address) float64 {
+	if a.StreetName == "" || a.StreetName != other.StreetName {
+		return 0
+	}
+
+	components := []struct {
+		values [2]string
+		weight float64
+	}{
+		{values: [2]string{a.HouseNumber, other.HouseNumber}, weight: houseNumberWeight},
+		{values: [2]string{a.StreetName, other.StreetName}, weight: streetNameWeight},
+		{values: [2]string{a.StreetType, other.StreetType}, weight: streetTypeWeight},
+		{values: [2]string{a.Unit, other.Unit}, weight: unitWeight},
+		{values: [2]string{a.POBox, other.POBox}, weight: poBoxWeight},
+	}
+
+	var matched, present float64
+	for _, component := range components {
+		if component.values[0] == "" && component.values[1] == "" {
+			continue
+		}
+
+		present += component.weight
+		if component.values[0] == component.values[1] {
+			matched += component.weight
+		}
+	}
+
+	return matched / present
+}
+
+var addressPunctuation = strings.NewReplacer(".", " ", ",", " ", "#", " # ")
+
+// addressTokens lower cases the address and splits it on spaces and punctuation, keeping hyphenated numbers together
+func addressTokens(value string) []string {
+	return strings.Fields(addressPunctuation.Replace(strings.ToLower(value)))
+}
+
+func extractPOBox(tokens []string) ([]string, string) {
+	for i := range tokens {
+		var number int
+		switch {
+		case matchTokens(tokens[i:], "p", "o", "box"):
+			number = i + 3
+		case matchTokens(tokens[i:], "po", "box"):
+			number = i + 2
+		default:
+			continue
+		}
+
+		if number >= len(tokens) {
+			return tokens, ""
+		}
+
+		remaining := append(append([]string{}, tokens[:i]...), tokens[number+1:]...)
+		return remaining, tokens[number]
+	}
+
+	return tokens, ""
+}
+
+func extractUnit(tokens []string) ([]string, string) {
+	for i := 0; i < len(tokens)-1; i++ {
+		if !unitDesignators[tokens[i]] {
+			continue
+		}
+
+		number := i + 1
+		if tokens[number] == "#" && number+1 < len(tokens) {
+			number++
+		}
+
+		if !strings.ContainsFunc(tokens[number], unicode.IsDigit) {
+			continue
+		}
+
+		remaining := append(append([]string{}, tokens[:i]...), tokens[number+1:]...)
+		return remaining, tokens[number]
+	}
+
+	return tokens, ""
+}
+
+func matchTokens(tokens []string, expected ...string) bool {
+	if len(tokens) < len(expected) {
+		return false
+	}
+
+	for i, token := range expected {
+		if tokens[i] != token {
+			return false
+		}
+	}
+	return true
+}
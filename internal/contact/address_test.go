@@ -0,0 +1,42 @@
+package contact_test
+
+import (
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	t.Run("when parsing a street address, it should split and expand its components", func(t *testing.T) {
+		address := contact.ParseAddress("449-6990 Tellus. Rd.")
+
+		assert.Equal(t, contact.Address{HouseNumber: "449-6990", StreetName: "tellus", StreetType: "road"}, address)
+	})
+
+	t.Run("when parsing an apartment, it should extract the unit", func(t *testing.T) {
+		address := contact.ParseAddress("Ap #312-8611 Lacus. Ave")
+
+		assert.Equal(t, contact.Address{Unit: "312-8611", StreetName: "lacus", StreetType: "avenue"}, address)
+	})
+
+	t.Run("when parsing a PO box, it should extract the box and the street", func(t *testing.T) {
+		address := contact.ParseAddress("P.O. Box 775, 8910 Arcu. Road")
+
+		assert.Equal(t, contact.Address{POBox: "775", HouseNumber: "8910", StreetName: "arcu", StreetType: "road"}, address)
+	})
+
+	t.Run("when parsing a street without number, it should only return the street", func(t *testing.T) {
+		address := contact.ParseAddress("Posuere road")
+
+		assert.Equal(t, contact.Address{StreetName: "posuere", StreetType: "road"}, address)
+	})
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	t.Run("when addresses only differ in abbreviations, it should normalize them to the same value", func(t *testing.T) {
+		assert.Equal(t, contact.NormalizeAddress("7641 Justo Avenue"), contact.NormalizeAddress("7641 Justo Ave."))
+		assert.Equal(t, "po box 452 2893 tincidunt road", contact.NormalizeAddress("P.O. Box 452, 2893 Tincidunt Rd."))
+		assert.Equal(t, "12 north main street", contact.NormalizeAddress("12 N Main St"))
+	})
+}
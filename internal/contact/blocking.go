@@ -0,0 +1,183 @@
+package contact
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	UnknownBlockKeyError = "unknown block key"
+)
+
+const (
+	ZipBlockKey         = "zip"
+	EmailDomainBlockKey = "email_domain"
+	LastInitialBlockKey = "last_initial"
+	LastSoundexBlockKey = "last_soundex"
+)
+
+// blockKeyFunc returns the block a contact belongs to, an empty key leaves the contact out of the block
+type blockKeyFunc func(contact Contact) string
+
+var blockKeyFuncs = map[string]blockKeyFunc{
+	ZipBlockKey: func(contact Contact) string {
+		return strings.TrimSpace(contact.ZipCode)
+	},
+	EmailDomainBlockKey: func(contact Contact) string {
+		email := CanonicalEmail(contact.Email)
+		at := strings.LastIndex(email, "@")
+		if at < 0 {
+			return ""
+		}
+		return email[at+1:]
+	},
+	LastInitialBlockKey: func(contact Contact) string {
+		lastName := strings.TrimSpace(contact.LastName)
+		if len(lastName) == 0 {
+			return ""
+		}
+		return strings.ToUpper(lastName[:1])
+	},
+	LastSoundexBlockKey: func(contact Contact) string {
+		return pkg.Soundex(contact.LastName)
+	},
+}
+
+// pair holds the indexes of two contacts to compare, i is always lower than j
+type pair struct {
+	i, j int
+}
+
+type blocker struct {
+	keys         []string
+	maxBlockSize int
+}
+
+func newBlocker(keys []string, maxBlockSize int) (blocker, error) {
+	for _, key := range keys {
+		if _, ok := blockKeyFuncs[key]; !ok {
+			return blocker{}, fmt.Errorf("%s: %q", UnknownBlockKeyError, key)
+		}
+	}
+
+	return blocker{
+		keys:         keys,
+		maxBlockSize: maxBlockSize,
+	}, nil
+}
+
+// candidatePairs returns the pairs to compare sorted in the same order as a nested loop over the contacts.
+// When sources is greater than 0 the contacts before it are only paired with the contacts from it.
+func (b blocker) candidatePairs(contacts []Contact, sources int) []pair {
+	end := len(contacts)
+	if sources > 0 {
+		end = sources
+	}
+	return b.pairsBetween(b.blockKeys(contacts), end, sources)
+}
+
+// blockKeys returns the blocks of every contact, one per block key in key order
+func (b blocker) blockKeys(contacts []Contact) [][]string {
+	blockKeys := make([][]string, len(contacts))
+	if len(b.keys) == 0 {
+		return blockKeys
+	}
+
+	for i, contact := range contacts {
+		blockKeys[i] = make([]string, len(b.keys))
+		for k, key := range b.keys {
+			blockKeys[i][k] = blockKeyFuncs[key](contact)
+		}
+	}
+	return blockKeys
+}
+
+// pairsBetween pairs every contact before end with the later contacts from start, sorted in nested loop order.
+// Without block keys every such pair is returned, otherwise only the ones sharing a block.
+func (b blocker) pairsBetween(blockKeys [][]string, end, start int) []pair {
+	contacts := len(blockKeys)
+	if len(b.keys) == 0 {
+		var total int
+		for i := 0; i < end; i++ {
+			total += max(contacts-max(i+1, start), 0)
+		}
+
+		pairs := make([]pair, 0, total)
+		for i := 0; i < end; i++ {
+			for j := max(i+1, start); j < contacts; j++ {
+				pairs = append(pairs, pair{i: i, j: j})
+			}
+		}
+		return pairs
+	}
+
+	candidates := make(map[pair]struct{})
+	for k := range b.keys {
+		for _, members := range b.blocks(blockKeys, k) {
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					if members[x] >= end || members[y] < start {
+						continue
+					}
+					candidates[pair{i: members[x], j: members[y]}] = struct{}{}
+				}
+			}
+		}
+	}
+
+	pairs := make([]pair, 0, len(candidates))
+	for p := range candidates {
+		pairs = append(pairs, p)
+	}
+
+	sort.Slice(pairs, func(x, y int) bool {
+		if pairs[x].i != pairs[y].i {
+			return pairs[x].i < pairs[y].i
+		}
+		return pairs[x].j < pairs[y].j
+	})
+
+	return pairs
+}
+
+// blocks groups the contacts by their block of the k-th key, an empty block leaves the contact out
+func (b blocker) blocks(blockKeys [][]string, k int) map[string][]int {
+	blocks := make(map[string][]int)
+	for i, keys := range blockKeys {
+		key := keys[k]
+		if key == "" {
+			continue
+		}
+		blocks[key] = append(blocks[key], i)
+	}
+
+	if b.maxBlockSize > 0 {
+		for key, members := range blocks {
+			if len(members) > b.maxBlockSize {
+				delete(blocks, key)
+			}
+		}
+	}
+
+	return blocks
+}
+
+// reductionRatio is the share of the exhaustive comparisons avoided by blocking
+func reductionRatio(contacts, sources, candidates int) float64 {
+	total := totalPairs(contacts, sources)
+	if total == 0 {
+		return 0
+	}
+	return 1 - float64(candidates)/float64(total)
+}
+
+// totalPairs is the number of exhaustive comparisons, only the pairs across datasets when sources is greater than 0
+func totalPairs(contacts, sources int) int {
+	if sources > 0 {
+		return sources * (contacts - sources)
+	}
+	return contacts * (contacts - 1) / 2
+}
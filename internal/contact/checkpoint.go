@@ -0,0 +1,256 @@
+package contact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	CheckpointMismatchError = "checkpoint does not match the current run"
+)
+
+// defaultCheckpointInterval is the number of scored pairs between checkpoints when no interval is configured
+const defaultCheckpointInterval = 100000
+
+// Checkpoint is the scoring progress of a run: the candidate pairs scored so far and the matches they produced
+type Checkpoint struct {
+	// InputChecksum identifies the input files the checkpoint was taken from, or the contacts read without input files
+	InputChecksum string `json:"input_checksum"`
+	// ConfigChecksum identifies the scoring options the checkpoint was taken with
+	ConfigChecksum string `json:"config_checksum"`
+	// Pairs is the number of candidate pairs of the run, it changes with the input and the blocking configuration
+	Pairs int `json:"pairs"`
+	// ScoredPairs is the number of candidate pairs scored, in candidate order
+	ScoredPairs int `json:"scored_pairs"`
+	// MatchCount is the number of matches of the scored pairs
+	MatchCount int `json:"match_count"`
+	// Matches are every match of the scored pairs when loaded, and only the ones found since the previous save of the
+	// run when saved, so each save only writes its new matches
+	Matches []CheckpointMatch `json:"-"`
+}
+
+// checkpointHeader is the checkpoint file, its matches are appended to a file of their own
+type checkpointHeader struct {
+	Checkpoint
+	// MatchesSize is the size of the matches file when the checkpoint was saved, what follows is not part of it
+	MatchesSize int64 `json:"matches_size"`
+}
+
+// CheckpointMatch is a match between the contacts at positions I and J of the input
+type CheckpointMatch struct {
+	I             int    `json:"i"`
+	J             int    `json:"j"`
+	AccuracyLevel int    `json:"accuracy_level"`
+	Duplicate     bool   `json:"duplicate,omitempty"`
+	PhoneticMatch string `json:"phonetic_match,omitempty"`
+}
+
+// CheckpointStore persists the scoring progress so an interrupted run can resume where it left off
+type CheckpointStore interface {
+	InputChecksum(ctx context.Context) (string, error)
+	// LoadCheckpoint reports false when there is no checkpoint to resume from
+	LoadCheckpoint(ctx context.Context) (Checkpoint, bool, error)
+	SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error
+	RemoveCheckpoint(ctx context.Context) error
+}
+
+type checkpointStore struct {
+	json           pkg.JSONConnector
+	checkpointPath string
+	matchesPath    string
+	inputPaths     []string
+	// matchesSize is the size of the matches file of the last checkpoint saved or loaded
+	matchesSize int64
+}
+
+// NewCheckpointStore keeps the checkpoint of the input files as JSON in checkpointPath, the reference file follows the
+// input when linking. The matches are appended as NDJSON to a file next to it, named after it.
+func NewCheckpointStore(json pkg.JSONConnector, checkpointPath string, inputPaths ...string) CheckpointStore {
+	return &checkpointStore{
+		json:           json,
+		checkpointPath: checkpointPath,
+		matchesPath:    strings.TrimSuffix(checkpointPath, filepath.Ext(checkpointPath)) + ".matches.ndjson",
+		inputPaths:     inputPaths,
+	}
+}
+
+// InputChecksum joins the checksums of every input file, it is empty without input files
+func (c *checkpointStore) InputChecksum(ctx context.Context) (string, error) {
+	checksums := make([]string, len(c.inputPaths))
+	for i, path := range c.inputPaths {
+		checksum, err := pkg.FileChecksum(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		checksums[i] = checksum
+	}
+	return strings.Join(checksums, ","), nil
+}
+
+func (c *checkpointStore) LoadCheckpoint(ctx context.Context) (Checkpoint, bool, error) {
+	var header checkpointHeader
+	err := c.json.ReadJSON(ctx, c.checkpointPath, &header)
+	if errors.Is(err, os.ErrNotExist) {
+		return Checkpoint{}, false, nil
+	}
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+
+	checkpoint := header.Checkpoint
+	if header.MatchCount > 0 {
+		for line, err := range c.json.ReadJSONLines(ctx, c.matchesPath, header.MatchesSize) {
+			if err != nil {
+				return Checkpoint{}, false, err
+			}
+
+			var m CheckpointMatch
+			if err = json.Unmarshal(line, &m); err != nil {
+				return Checkpoint{}, false, fmt.Errorf("error reading checkpoint match: %w", err)
+			}
+			checkpoint.Matches = append(checkpoint.Matches, m)
+		}
+	}
+
+	if len(checkpoint.Matches) != checkpoint.MatchCount {
+		return Checkpoint{}, false, fmt.Errorf("%s: expected %d matches, the checkpoint has %d",
+			CheckpointMismatchError, checkpoint.MatchCount, len(checkpoint.Matches))
+	}
+
+	c.matchesSize = header.MatchesSize
+	return checkpoint, true, nil
+}
+
+// SaveCheckpoint appends the new matches and then replaces the checkpoint file, so an interrupted save leaves the
+// previous checkpoint. The first save of a run, holding every match, starts the matches file over.
+func (c *checkpointStore) SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error {
+	size := c.matchesSize
+	if len(checkpoint.Matches) == checkpoint.MatchCount {
+		size = 0
+	}
+
+	matches := make([]any, len(checkpoint.Matches))
+	for i, m := range checkpoint.Matches {
+		matches[i] = m
+	}
+
+	size, err := c.json.AppendJSONLines(ctx, c.matchesPath, size, matches)
+	if err != nil {
+		return err
+	}
+
+	if err = c.json.WriteJSON(ctx, c.checkpointPath, checkpointHeader{Checkpoint: checkpoint, MatchesSize: size}); err != nil {
+		return err
+	}
+
+	c.matchesSize = size
+	return nil
+}
+
+func (c *checkpointStore) RemoveCheckpoint(ctx context.Context) error {
+	if err := c.json.Remove(ctx, c.checkpointPath); err != nil {
+		return err
+	}
+
+	c.matchesSize = 0
+	return c.json.Remove(ctx, c.matchesPath)
+}
+
+// validate checks the checkpoint was taken from the same input file, scoring options and candidate pairs
+func (c Checkpoint) validate(inputChecksum, configChecksum string, contacts, pairs int) error {
+	if c.InputChecksum != inputChecksum {
+		return fmt.Errorf("%s: the input checksum changed", CheckpointMismatchError)
+	}
+
+	if c.Pairs != pairs || c.ScoredPairs < 0 || c.ScoredPairs > pairs {
+		return fmt.Errorf("%s: expected %d candidate pairs, the checkpoint has %d", CheckpointMismatchError, pairs, c.Pairs)
+	}
+
+	if c.ConfigChecksum != configChecksum {
+		return fmt.Errorf("%s: the scoring options changed", CheckpointMismatchError)
+	}
+
+	for _, m := range c.Matches {
+		if m.I < 0 || m.I >= m.J || m.J >= contacts {
+			return fmt.Errorf("%s: invalid match %d-%d", CheckpointMismatchError, m.I, m.J)
+		}
+	}
+
+	return nil
+}
+
+// contactsChecksum identifies the contacts and their order, from the checksum of every contact
+func contactsChecksum(contacts []Contact) string {
+	hash := sha256.New()
+	for _, contact := range contacts {
+		hash.Write([]byte(contactChecksum(contact)))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// scoringChecksum identifies the options the matches depend on. The options that only shape the outputs, such as
+// clustering and merge, or the run, such as the workers, are left out.
+func (c Config) scoringChecksum() (string, error) {
+	scoring := c
+	scoring.MaxRejects = 0
+	scoring.Explain = false
+	scoring.Clustering = ClusteringConfig{}
+	scoring.Merge = MergeConfig{}
+	scoring.Workers = 0
+	scoring.CheckpointInterval = 0
+	scoring.Resume = false
+
+	var nicknames map[string]map[string]bool
+	if c.Nicknames != nil {
+		nicknames = c.Nicknames.givenNames
+	}
+
+	options, err := json.Marshal(struct {
+		Config
+		Nicknames map[string]map[string]bool `json:"nicknames"`
+	}{Config: scoring, Nicknames: nicknames})
+	if err != nil {
+		return "", err
+	}
+
+	checksum := sha256.Sum256(options)
+	return hex.EncodeToString(checksum[:]), nil
+}
+
+func toCheckpointMatches(matches []match) []CheckpointMatch {
+	checkpointMatches := make([]CheckpointMatch, len(matches))
+	for i, m := range matches {
+		checkpointMatches[i] = CheckpointMatch{
+			I:             m.i,
+			J:             m.j,
+			AccuracyLevel: m.accuracyLevel,
+			Duplicate:     m.duplicate,
+			PhoneticMatch: m.phoneticMatch,
+		}
+	}
+	return checkpointMatches
+}
+
+func fromCheckpointMatches(checkpointMatches []CheckpointMatch) []match {
+	matches := make([]match, len(checkpointMatches))
+	for i, m := range checkpointMatches {
+		matches[i] = match{
+			pair: pair{i: m.I, j: m.J},
+			comparison: comparison{
+				accuracyLevel: m.AccuracyLevel,
+				duplicate:     m.Duplicate,
+				phoneticMatch: m.PhoneticMatch,
+			},
+		}
+	}
+	return matches
+}
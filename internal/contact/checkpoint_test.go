@@ -0,0 +1,280 @@
+package contact_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContactService_EvaluateCheckpoints(t *testing.T) {
+	logger := logrus.New()
+	mockContacts := generatedContacts(120)
+
+	newRepository := func() *mocks.RepositoryMock {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+		return mockRepo
+	}
+
+	newConfig := func(store contact.CheckpointStore, resume bool) contact.Config {
+		config := contact.DefaultConfig()
+		config.Clustering.Enabled = false
+		config.Merge.Enabled = false
+		// A single worker completes the chunks in order, so every checkpoint interval is saved
+		config.Workers = 1
+		config.Checkpoints = store
+		config.CheckpointInterval = 2000
+		config.Resume = resume
+		return config
+	}
+
+	t.Run("when resuming from a checkpoint, it should return the same output as a full run", func(t *testing.T) {
+		var checkpoints []contact.Checkpoint
+		store := new(mocks.CheckpointStoreMock)
+		store.On("InputChecksum", mock.Anything).Return("checksum", nil)
+		store.On("SaveCheckpoint", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			checkpoints = append(checkpoints, args.Get(1).(contact.Checkpoint))
+		}).Return(nil)
+		store.On("RemoveCheckpoint", mock.Anything).Return(nil)
+
+		full, err := collect(contact.NewContactService(logger, newRepository(), newConfig(store, false)).Evaluate(context.Background()))
+		assert.Nil(t, err)
+		assert.Greater(t, len(checkpoints), 1)
+		assert.Equal(t, "checksum", checkpoints[0].InputChecksum)
+		assert.Greater(t, checkpoints[0].ScoredPairs, 0)
+		assert.Less(t, checkpoints[1].ScoredPairs, checkpoints[1].Pairs)
+
+		// Each save only holds its new matches, the store loads them all
+		resumed := checkpoints[1]
+		resumed.Matches = append(checkpoints[0].Matches, checkpoints[1].Matches...)
+		assert.Equal(t, resumed.MatchCount, len(resumed.Matches))
+
+		resumeStore := new(mocks.CheckpointStoreMock)
+		resumeStore.On("InputChecksum", mock.Anything).Return("checksum", nil)
+		resumeStore.On("LoadCheckpoint", mock.Anything).Return(resumed, true, nil)
+		resumeStore.On("SaveCheckpoint", mock.Anything, mock.Anything).Return(nil).Maybe()
+		resumeStore.On("RemoveCheckpoint", mock.Anything).Return(nil)
+
+		resumeConfig := newConfig(resumeStore, true)
+		resumeConfig.Workers = 4
+		results, err := collect(contact.NewContactService(logger, newRepository(), resumeConfig).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, full, results)
+		store.AssertExpectations(t)
+		resumeStore.AssertExpectations(t)
+	})
+
+	t.Run("when resuming without a checkpoint, it should score every pair", func(t *testing.T) {
+		store := new(mocks.CheckpointStoreMock)
+		store.On("InputChecksum", mock.Anything).Return("checksum", nil)
+		store.On("LoadCheckpoint", mock.Anything).Return(contact.Checkpoint{}, false, nil)
+		store.On("SaveCheckpoint", mock.Anything, mock.Anything).Return(nil).Maybe()
+		store.On("RemoveCheckpoint", mock.Anything).Return(nil)
+
+		results, err := collect(contact.NewContactService(logger, newRepository(), newConfig(store, true)).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, results)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("when the input file changed since the checkpoint, it should return an error", func(t *testing.T) {
+		store := new(mocks.CheckpointStoreMock)
+		store.On("InputChecksum", mock.Anything).Return("new checksum", nil)
+		store.On("LoadCheckpoint", mock.Anything).Return(contact.Checkpoint{InputChecksum: "old checksum"}, true, nil)
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, newConfig(store, true)).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.CheckpointMismatchError)
+		store.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when there is no input file, it should tie the checkpoint to the contacts read", func(t *testing.T) {
+		var checkpoint contact.Checkpoint
+		store := new(mocks.CheckpointStoreMock)
+		store.On("InputChecksum", mock.Anything).Return("", nil)
+		store.On("SaveCheckpoint", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			checkpoint = args.Get(1).(contact.Checkpoint)
+		}).Return(nil)
+		store.On("RemoveCheckpoint", mock.Anything).Return(nil)
+
+		_, err := collect(contact.NewContactService(logger, newRepository(), newConfig(store, false)).Evaluate(context.Background()))
+		assert.Nil(t, err)
+		assert.NotEmpty(t, checkpoint.InputChecksum)
+
+		// The same contacts in another order are not the contacts the checkpoint positions refer to
+		reordered := slices.Clone(mockContacts)
+		reordered[0], reordered[1] = reordered[1], reordered[0]
+		resumeStore := new(mocks.CheckpointStoreMock)
+		resumeStore.On("InputChecksum", mock.Anything).Return("", nil)
+		resumeStore.On("LoadCheckpoint", mock.Anything).Return(checkpoint, true, nil)
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(reordered, nil)
+
+		_, err = collect(contact.NewContactService(logger, mockRepo, newConfig(resumeStore, true)).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.CheckpointMismatchError+": the input checksum changed", err.Error())
+		resumeStore.AssertExpectations(t)
+	})
+
+	t.Run("when the scoring options changed since the checkpoint, it should return an error", func(t *testing.T) {
+		var checkpoint contact.Checkpoint
+		store := new(mocks.CheckpointStoreMock)
+		store.On("InputChecksum", mock.Anything).Return("checksum", nil)
+		store.On("SaveCheckpoint", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			checkpoint = args.Get(1).(contact.Checkpoint)
+		}).Return(nil)
+		store.On("RemoveCheckpoint", mock.Anything).Return(nil)
+		_, err := collect(contact.NewContactService(logger, newRepository(), newConfig(store, false)).Evaluate(context.Background()))
+		assert.Nil(t, err)
+
+		resumeStore := new(mocks.CheckpointStoreMock)
+		resumeStore.On("InputChecksum", mock.Anything).Return("checksum", nil)
+		resumeStore.On("LoadCheckpoint", mock.Anything).Return(checkpoint, true, nil)
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+
+		config := newConfig(resumeStore, true)
+		config.Weights = map[contact.Field]float64{contact.EmailField: 2}
+		_, err = collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.CheckpointMismatchError+": the scoring options changed", err.Error())
+		resumeStore.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the candidate pairs changed since the checkpoint, it should return an error", func(t *testing.T) {
+		store := new(mocks.CheckpointStoreMock)
+		store.On("InputChecksum", mock.Anything).Return("checksum", nil)
+		store.On("LoadCheckpoint", mock.Anything).Return(contact.Checkpoint{InputChecksum: "checksum", Pairs: 10}, true, nil)
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, newConfig(store, true)).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.CheckpointMismatchError)
+		store.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the run is cancelled, it should save the progress before returning", func(t *testing.T) {
+		store := new(mocks.CheckpointStoreMock)
+		store.On("InputChecksum", mock.Anything).Return("checksum", nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		store.On("SaveCheckpoint", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			cancel()
+		}).Return(nil)
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, newConfig(store, false)).Evaluate(ctx))
+
+		assert.ErrorIs(t, err, context.Canceled)
+		store.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestCheckpointStore(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.csv")
+	checkpointPath := filepath.Join(dir, "checkpoint.json")
+	assert.Nil(t, os.WriteFile(inputPath, []byte("contactID,name\n1,John\n"), 0o644))
+	store := contact.NewCheckpointStore(pkg.NewJSONConnector(), checkpointPath, inputPath)
+	ctx := context.Background()
+
+	t.Run("when there is no checkpoint, it should report it was not found", func(t *testing.T) {
+		_, found, err := store.LoadCheckpoint(ctx)
+
+		assert.Nil(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("when a checkpoint is saved, it should load it back", func(t *testing.T) {
+		checkpoint := contact.Checkpoint{
+			InputChecksum:  "checksum",
+			ConfigChecksum: "config",
+			Pairs:          10,
+			ScoredPairs:    4,
+			MatchCount:     1,
+			Matches:        []contact.CheckpointMatch{{I: 0, J: 3, AccuracyLevel: 2, PhoneticMatch: "last_name:soundex"}},
+		}
+
+		assert.Nil(t, store.SaveCheckpoint(ctx, checkpoint))
+		loaded, found, err := store.LoadCheckpoint(ctx)
+
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, checkpoint, loaded)
+	})
+
+	t.Run("when a later checkpoint is saved, it should append its new matches to the previous ones", func(t *testing.T) {
+		first := contact.Checkpoint{InputChecksum: "checksum", Pairs: 10, ScoredPairs: 4, MatchCount: 1,
+			Matches: []contact.CheckpointMatch{{I: 0, J: 3, AccuracyLevel: 2}}}
+		second := contact.Checkpoint{InputChecksum: "checksum", Pairs: 10, ScoredPairs: 8, MatchCount: 2,
+			Matches: []contact.CheckpointMatch{{I: 4, J: 6, AccuracyLevel: 5, Duplicate: true}}}
+
+		assert.Nil(t, store.SaveCheckpoint(ctx, first))
+		assert.Nil(t, store.SaveCheckpoint(ctx, second))
+		loaded, found, err := store.LoadCheckpoint(ctx)
+
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, 8, loaded.ScoredPairs)
+		assert.Equal(t, append(first.Matches, second.Matches...), loaded.Matches)
+	})
+
+	t.Run("when a new run saves its first checkpoint, it should drop the matches of the previous run", func(t *testing.T) {
+		checkpoint := contact.Checkpoint{InputChecksum: "checksum", Pairs: 10, ScoredPairs: 2, MatchCount: 1,
+			Matches: []contact.CheckpointMatch{{I: 1, J: 2, AccuracyLevel: 1}}}
+
+		assert.Nil(t, contact.NewCheckpointStore(pkg.NewJSONConnector(), checkpointPath, inputPath).SaveCheckpoint(ctx, checkpoint))
+		loaded, found, err := store.LoadCheckpoint(ctx)
+
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, checkpoint, loaded)
+	})
+
+	t.Run("when the checkpoint is removed, it should not be found", func(t *testing.T) {
+		assert.Nil(t, store.RemoveCheckpoint(ctx))
+		_, found, err := store.LoadCheckpoint(ctx)
+
+		assert.Nil(t, err)
+		assert.False(t, found)
+		entries, err := os.ReadDir(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(entries))
+	})
+
+	t.Run("when the input file changes, it should change its checksum", func(t *testing.T) {
+		before, err := store.InputChecksum(ctx)
+		assert.Nil(t, err)
+
+		assert.Nil(t, os.WriteFile(inputPath, []byte("contactID,name\n1,Jane\n"), 0o644))
+		after, err := store.InputChecksum(ctx)
+
+		assert.Nil(t, err)
+		assert.NotEqual(t, before, after)
+	})
+}
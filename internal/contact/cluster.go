@@ -0,0 +1,156 @@
+package contact
+
+import "fmt"
+
+const (
+	UnknownClusteringMethodError = "unknown clustering method"
+)
+
+const (
+	ConnectedComponentsClustering = "connected_components"
+	CorrelationClustering         = "correlation"
+)
+
+// ClusteringConfig groups matched contacts into entities
+type ClusteringConfig struct {
+	Enabled bool `json:"enabled"`
+	// Method is either connected_components, where matches are transitive, or the stricter correlation clustering
+	Method string `json:"method"`
+	// MinAccuracy is the lowest accuracy level linking two contacts, duplicates are always linked
+	MinAccuracy int `json:"min_accuracy"`
+}
+
+func (c ClusteringConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Method != ConnectedComponentsClustering && c.Method != CorrelationClustering {
+		return fmt.Errorf("%s: %q", UnknownClusteringMethodError, c.Method)
+	}
+
+	if _, err := MapLevelToAccuracy(c.MinAccuracy); err != nil {
+		return fmt.Errorf("min accuracy %d: %w", c.MinAccuracy, err)
+	}
+
+	return nil
+}
+
+// clusterContacts assigns every contact a cluster ID, numbered from 1 in the order clusters first appear in the input.
+// previous holds the clusters of the first contacts in an earlier run, their members stay linked to each other.
+func clusterContacts(contacts []Contact, matches []match, previous []int, config ClusteringConfig) []ClusterAssignment {
+	neighbors := make([][]int, len(contacts))
+	members := make(map[int][]int)
+	for i, clusterID := range previous {
+		if clusterID == 0 {
+			continue
+		}
+		for _, member := range members[clusterID] {
+			neighbors[i] = append(neighbors[i], member)
+			neighbors[member] = append(neighbors[member], i)
+		}
+		members[clusterID] = append(members[clusterID], i)
+	}
+
+	for _, m := range matches {
+		if m.duplicate || m.accuracyLevel >= config.MinAccuracy {
+			neighbors[m.i] = append(neighbors[m.i], m.j)
+			neighbors[m.j] = append(neighbors[m.j], m.i)
+		}
+	}
+
+	var clusterOf []int
+	if config.Method == CorrelationClustering {
+		clusterOf = correlationClusters(neighbors)
+	} else {
+		clusterOf = connectedComponents(neighbors)
+	}
+
+	assignments := make([]ClusterAssignment, len(contacts))
+	for i, contact := range contacts {
+		assignments[i] = ClusterAssignment{
+			ContactID: contact.ContactID,
+			ClusterID: clusterOf[i],
+		}
+	}
+
+	return assignments
+}
+
+// connectedComponents links every pair of contacts joined by a chain of matches using union-find
+func connectedComponents(neighbors [][]int) []int {
+	parent := make([]int, len(neighbors))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i, linked := range neighbors {
+		for _, j := range linked {
+			root1, root2 := find(i), find(j)
+			// The lowest index is kept as root, so clusters are numbered by their first contact
+			if root1 < root2 {
+				parent[root2] = root1
+			} else if root2 < root1 {
+				parent[root1] = root2
+			}
+		}
+	}
+
+	clusterIDs := make(map[int]int)
+	clusterOf := make([]int, len(neighbors))
+	for i := range neighbors {
+		root := find(i)
+		if _, ok := clusterIDs[root]; !ok {
+			clusterIDs[root] = len(clusterIDs) + 1
+		}
+		clusterOf[i] = clusterIDs[root]
+	}
+
+	return clusterOf
+}
+
+// correlationClusters takes each unclustered contact, in input order, as the pivot of a new cluster.
+// A neighbor of the pivot only joins when it matches more than half of the cluster members, so unlike
+// connected components a chain of weak links can't merge unrelated contacts.
+func correlationClusters(neighbors [][]int) []int {
+	clusterOf := make([]int, len(neighbors))
+	var clusters int
+
+	for pivot := range neighbors {
+		if clusterOf[pivot] != 0 {
+			continue
+		}
+
+		clusters++
+		clusterOf[pivot] = clusters
+		members := []int{pivot}
+
+		for _, candidate := range neighbors[pivot] {
+			if clusterOf[candidate] != 0 {
+				continue
+			}
+
+			var agreements int
+			for _, linked := range neighbors[candidate] {
+				if clusterOf[linked] == clusters {
+					agreements++
+				}
+			}
+
+			if agreements*2 > len(members) {
+				clusterOf[candidate] = clusters
+				members = append(members, candidate)
+			}
+		}
+	}
+
+	return clusterOf
+}
@@ -0,0 +1,133 @@
+package contact
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	MissingColumnError        = "missing required columns"
+	InvalidColumnMappingError = "invalid column mapping record"
+)
+
+// Fields read from the input file that are not compared
+const (
+	ContactIDField Field = "contact_id"
+	SourceField    Field = "source"
+	UpdatedAtField Field = "updated_at"
+)
+
+var requiredFields = []Field{ContactIDField, FirstNameField, LastNameField, EmailField, ZipCodeField, AddressField}
+
+var mappedFields = append(append([]Field{}, requiredFields...), SourceField, UpdatedAtField)
+
+// ColumnMapping lists the header names accepted for each field, names are matched ignoring case, spaces, dashes and underscores
+type ColumnMapping map[Field][]string
+
+// DefaultColumnMapping accepts the field names and the header of the original input file "contactID,name,name1,email,postalZip,address"
+func DefaultColumnMapping() ColumnMapping {
+	return ColumnMapping{
+		ContactIDField: {"contact_id", "id"},
+		FirstNameField: {"first_name", "name", "given_name"},
+		LastNameField:  {"last_name", "name1", "surname", "family_name"},
+		EmailField:     {"email", "email_address"},
+		ZipCodeField:   {"zip_code", "zip", "postal_zip", "postal_code"},
+		AddressField:   {"address", "street_address"},
+		SourceField:    {"source"},
+		UpdatedAtField: {"updated_at"},
+	}
+}
+
+// LoadColumnMapping reads a "field,column" CSV file, the columns it lists replace the default ones of their field
+func LoadColumnMapping(ctx context.Context, csvConnector pkg.CSVConnector, filePath string) (ColumnMapping, error) {
+	records, err := csvConnector.ReadCSV(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(ColumnMapping)
+	if err = loaded.Load(records); err != nil {
+		return nil, err
+	}
+
+	mapping := DefaultColumnMapping()
+	for field, columns := range loaded {
+		mapping[field] = columns
+	}
+
+	return mapping, nil
+}
+
+// Load adds "field,column" records to the mapping, the first record is the header
+func (m ColumnMapping) Load(records [][]string) error {
+	if len(records) < 1 {
+		return nil
+	}
+
+	for i, record := range records[1:] {
+		if len(record) != 2 || strings.TrimSpace(record[1]) == "" {
+			return fmt.Errorf("%s %d: expected a field and a column", InvalidColumnMappingError, i+1)
+		}
+
+		field := Field(strings.TrimSpace(record[0]))
+		if !isMappedField(field) {
+			return fmt.Errorf("%s %d: unknown field %q", InvalidColumnMappingError, i+1, field)
+		}
+
+		m[field] = append(m[field], strings.TrimSpace(record[1]))
+	}
+
+	return nil
+}
+
+// resolve finds the position of each field in the header, reporting every required field without a column
+func (m ColumnMapping) resolve(header []string) (map[Field]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		key := columnKey(name)
+		if _, ok := positions[key]; !ok {
+			positions[key] = i
+		}
+	}
+
+	columns := make(map[Field]int)
+	for _, field := range mappedFields {
+		for _, name := range m[field] {
+			if position, ok := positions[columnKey(name)]; ok {
+				columns[field] = position
+				break
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range requiredFields {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, string(field))
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: %s", MissingColumnError, strings.Join(missing, ", "))
+	}
+
+	return columns, nil
+}
+
+var columnSeparators = strings.NewReplacer("_", "", "-", "", " ", "")
+
+func columnKey(name string) string {
+	return columnSeparators.Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func isMappedField(field Field) bool {
+	for _, mapped := range mappedFields {
+		if field == mapped {
+			return true
+		}
+	}
+	return false
+}
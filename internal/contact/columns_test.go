@@ -0,0 +1,69 @@
+package contact_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestColumnMapping_Load(t *testing.T) {
+	t.Run("when the records are valid, it should add the columns to their fields", func(t *testing.T) {
+		mapping := make(contact.ColumnMapping)
+
+		err := mapping.Load([][]string{
+			{"field", "column"},
+			{"first_name", "Given"},
+			{"first_name", "Forename"},
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Given", "Forename"}, mapping[contact.FirstNameField])
+	})
+
+	t.Run("when a field is unknown, it should return an error", func(t *testing.T) {
+		mapping := make(contact.ColumnMapping)
+
+		err := mapping.Load([][]string{{"field", "column"}, {"phone", "Phone"}})
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidColumnMappingError)
+	})
+
+	t.Run("when a record has no column, it should return an error", func(t *testing.T) {
+		mapping := make(contact.ColumnMapping)
+
+		err := mapping.Load([][]string{{"field", "column"}, {"email", " "}})
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidColumnMappingError)
+	})
+}
+
+func TestLoadColumnMapping(t *testing.T) {
+	t.Run("when the file is loaded, it should replace the default columns of the mapped fields only", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		mockCsv.On("ReadCSV", mock.Anything, "columns.csv").Return([][]string{{"field", "column"}, {"email", "mail"}}, nil)
+
+		mapping, err := contact.LoadColumnMapping(context.Background(), mockCsv, "columns.csv")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"mail"}, mapping[contact.EmailField])
+		assert.Equal(t, contact.DefaultColumnMapping()[contact.FirstNameField], mapping[contact.FirstNameField])
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when the file can't be read, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		mockCsv.On("ReadCSV", mock.Anything, "columns.csv").Return([][]string{}, errors.New("failed to read CSV"))
+
+		_, err := contact.LoadColumnMapping(context.Background(), mockCsv, "columns.csv")
+
+		assert.NotNil(t, err)
+		mockCsv.AssertExpectations(t)
+	})
+}
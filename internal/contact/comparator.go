@@ -0,0 +1,82 @@
+package contact
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	UnknownComparatorError = "unknown comparator"
+	InvalidThresholdError  = "invalid threshold"
+)
+
+type Field string
+
+const (
+	FirstNameField Field = "first_name"
+	LastNameField  Field = "last_name"
+	EmailField     Field = "email"
+	ZipCodeField   Field = "zip_code"
+	AddressField   Field = "address"
+)
+
+type Comparator string
+
+const (
+	ExactComparator              Comparator = "exact"
+	LevenshteinComparator        Comparator = "levenshtein"
+	DamerauLevenshteinComparator Comparator = "damerau_levenshtein"
+	JaroWinklerComparator        Comparator = "jaro_winkler"
+	TokenSetComparator           Comparator = "token_set"
+)
+
+var similarityFuncs = map[Comparator]func(a, b string) float64{
+	ExactComparator: func(a, b string) float64 {
+		if a == b {
+			return 1
+		}
+		return 0
+	},
+	LevenshteinComparator:        pkg.LevenshteinSimilarity,
+	DamerauLevenshteinComparator: pkg.DamerauLevenshteinSimilarity,
+	JaroWinklerComparator:        pkg.JaroWinkler,
+	TokenSetComparator:           pkg.TokenSetRatio,
+}
+
+// FieldComparator awards the similarity of two field values as partial credit when it reaches the threshold
+type FieldComparator struct {
+	Comparator Comparator `json:"comparator"`
+	Threshold  float64    `json:"threshold"`
+}
+
+func (f FieldComparator) validate() error {
+	if _, ok := similarityFuncs[f.Comparator]; !ok {
+		return fmt.Errorf("%s: %q", UnknownComparatorError, f.Comparator)
+	}
+
+	if f.Threshold <= 0 || f.Threshold > 1 {
+		return fmt.Errorf("%s: %v, it must be greater than 0 and at most 1", InvalidThresholdError, f.Threshold)
+	}
+
+	return nil
+}
+
+// similarity compares the values case-insensitively, returning 0 when they are below the threshold
+func (f FieldComparator) similarity(value1, value2 string) float64 {
+	// An edit distance is at least the length difference, skip values that can't reach the threshold
+	if f.Comparator == LevenshteinComparator || f.Comparator == DamerauLevenshteinComparator {
+		length1, length2 := utf8.RuneCountInString(value1), utf8.RuneCountInString(value2)
+		if 1-float64(max(length1, length2)-min(length1, length2))/float64(max(length1, length2)) < f.Threshold {
+			return 0
+		}
+	}
+
+	similarity := similarityFuncs[f.Comparator](strings.ToLower(value1), strings.ToLower(value2))
+	if similarity < f.Threshold {
+		return 0
+	}
+	return similarity
+}
@@ -0,0 +1,108 @@
+package contact
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+// Config holds the tunable options of the contact service.
+// The zero value compares every pair of contacts and only scores exact matches, while the command line tool starts
+// from DefaultConfig.
+type Config struct {
+	// BlockKeys are the blocking keys used to generate candidate pairs, their candidate sets are unioned.
+	// When empty every pair of contacts is compared.
+	BlockKeys []string `json:"block_keys"`
+	// MaxBlockSize skips blocks with more contacts than this value, 0 means no limit.
+	MaxBlockSize int `json:"max_block_size"`
+	// MaxRejects fails the run when more input rows are rejected, 0 means no limit.
+	MaxRejects int `json:"max_rejects"`
+	// Linkage links the source contacts to the reference contacts, only pairs across both datasets are compared.
+	// Clustering and merge must be disabled since the contact IDs of both datasets may overlap.
+	Linkage bool `json:"linkage"`
+	// ScoringModel selects how a pair is scored, empty uses the heuristic model.
+	ScoringModel ScoringModel `json:"scoring_model"`
+	// FellegiSunter tunes the estimation of the Fellegi-Sunter model.
+	FellegiSunter FellegiSunterConfig `json:"fellegi_sunter"`
+	// Weights multiply the credit of each field in the heuristic model, fields without a weight weigh 1.
+	Weights map[Field]float64 `json:"weights"`
+	// AccuracyCutoffs are the heuristic scores from which a pair reaches each accuracy level, empty uses 1, 2, 3, 4 and 5.
+	AccuracyCutoffs AccuracyCutoffs `json:"accuracy_cutoffs"`
+	// Comparators award partial credit to fields that are not an exact match, fields without one only score exact matches.
+	Comparators map[Field]FieldComparator `json:"comparators"`
+	// PhoneticEncoders replace the first letter check on names, they are tried in order until one recognises the names as alike.
+	PhoneticEncoders []PhoneticEncoder `json:"phonetic_encoders"`
+	// Nicknames credit first names that are nicknames of the same given name, nil disables the check.
+	Nicknames *NicknameTable `json:"-"`
+	// NormalizeEmails compares emails by their canonical form, outputs keep the original values.
+	NormalizeEmails bool `json:"normalize_emails"`
+	// ParseAddresses compares addresses in their normalized form and gives partial credit to matching components.
+	ParseAddresses bool `json:"parse_addresses"`
+	// Validation compares the values that are not valid for their field as missing and reports the data quality.
+	Validation ValidationConfig `json:"validation"`
+	// Explain breaks the score of every match down by field and writes the explanations.
+	Explain bool `json:"explain"`
+	// Clustering groups the matches into entities and writes the cluster of each contact.
+	Clustering ClusteringConfig `json:"clustering"`
+	// Merge builds a golden record per cluster and writes the deduplicated contacts.
+	Merge MergeConfig `json:"merge"`
+	// Workers is the number of goroutines scoring the candidate pairs, 0 or 1 scores them sequentially.
+	Workers int `json:"workers"`
+	// Index saves every contact with its blocks, normalized fields and cluster after the run, nil disables the index.
+	Index IndexStore `json:"-"`
+	// Incremental only matches the new and changed contacts of the input against the index, the indexed contacts
+	// keep their clusters and only the new matches are written.
+	Incremental bool `json:"incremental"`
+	// Checkpoints saves the scoring progress every CheckpointInterval candidate pairs, nil disables the checkpoints.
+	Checkpoints CheckpointStore `json:"-"`
+	// CheckpointInterval is the number of candidate pairs scored between checkpoints, 0 uses 100000.
+	CheckpointInterval int `json:"checkpoint_interval"`
+	// Resume continues from the stored checkpoint, it fails when the checkpoint was taken from a different input file.
+	Resume bool `json:"resume"`
+}
+
+// DefaultConfig returns the configuration used when running the command line tool: blocking, fuzzy comparators,
+// phonetic and nickname matching, email and address normalization, validation, clustering and merge on every CPU
+func DefaultConfig() Config {
+	return Config{
+		BlockKeys: []string{ZipBlockKey, EmailDomainBlockKey, LastSoundexBlockKey},
+		Comparators: map[Field]FieldComparator{
+			FirstNameField: {Comparator: JaroWinklerComparator, Threshold: 0.9},
+			LastNameField:  {Comparator: JaroWinklerComparator, Threshold: 0.9},
+			EmailField:     {Comparator: DamerauLevenshteinComparator, Threshold: 0.9},
+			AddressField:   {Comparator: TokenSetComparator, Threshold: 0.85},
+		},
+		PhoneticEncoders: []PhoneticEncoder{DoubleMetaphoneEncoder, MetaphoneEncoder, SoundexEncoder},
+		Nicknames:        NewNicknameTable(),
+		NormalizeEmails:  true,
+		ParseAddresses:   true,
+		Validation:       ValidationConfig{Enabled: true},
+		Clustering: ClusteringConfig{
+			Enabled:     true,
+			Method:      ConnectedComponentsClustering,
+			MinAccuracy: 4,
+		},
+		Merge: MergeConfig{
+			Enabled: true,
+			Rules: map[Field]SurvivorshipRule{
+				FirstNameField: LongestRule,
+				LastNameField:  MostFrequentRule,
+				EmailField:     MostRecentRule,
+				ZipCodeField:   MostRecentRule,
+				AddressField:   MostRecentRule,
+			},
+		},
+		Workers: runtime.NumCPU(),
+	}
+}
+
+// LoadConfig reads a JSON config file on top of DefaultConfig, so the file only needs the options it changes.
+// Comparators and weights are merged by field, lists replace the default ones.
+func LoadConfig(ctx context.Context, json pkg.JSONConnector, path string) (Config, error) {
+	config := DefaultConfig()
+	if err := json.ReadJSON(ctx, path, &config); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
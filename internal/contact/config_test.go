@@ -0,0 +1,64 @@
+package contact_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("when the file changes some options, it should keep the defaults of the others", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		err := os.WriteFile(path, []byte(`{
+			"weights": {"email": 2, "zip_code": 0.5},
+			"accuracy_cutoffs": [1, 1.5, 2.5, 3.5, 4.5],
+			"comparators": {"email": {"comparator": "levenshtein", "threshold": 0.8}}
+		}`), 0o644)
+		assert.Nil(t, err)
+
+		config, err := contact.LoadConfig(ctx, pkg.NewJSONConnector(), path)
+
+		assert.Nil(t, err)
+		defaults := contact.DefaultConfig()
+		assert.Equal(t, map[contact.Field]float64{contact.EmailField: 2, contact.ZipCodeField: 0.5}, config.Weights)
+		assert.Equal(t, contact.AccuracyCutoffs{1, 1.5, 2.5, 3.5, 4.5}, config.AccuracyCutoffs)
+		assert.Equal(t, contact.FieldComparator{Comparator: contact.LevenshteinComparator, Threshold: 0.8}, config.Comparators[contact.EmailField])
+		assert.Equal(t, defaults.Comparators[contact.AddressField], config.Comparators[contact.AddressField])
+		assert.Equal(t, defaults.PhoneticEncoders, config.PhoneticEncoders)
+		assert.NotNil(t, config.Nicknames)
+	})
+
+	t.Run("when the file is not valid JSON, it should return an error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		err := os.WriteFile(path, []byte(`{"weights":`), 0o644)
+		assert.Nil(t, err)
+
+		_, err = contact.LoadConfig(ctx, pkg.NewJSONConnector(), path)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("when the file has an unknown option, it should return an error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		err := os.WriteFile(path, []byte(`{"block_key": ["zip"]}`), 0o644)
+		assert.Nil(t, err)
+
+		_, err = contact.LoadConfig(ctx, pkg.NewJSONConnector(), path)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), `unknown field "block_key"`)
+	})
+
+	t.Run("when the file does not exist, it should return an error", func(t *testing.T) {
+		_, err := contact.LoadConfig(ctx, pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "missing.json"))
+
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,228 @@
+package contact
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	DatabaseSourceError      = "no table nor query to read the contacts from"
+	OutputTableConflictError = "output table is also read as input, set another output prefix"
+	InvalidOutputPrefixError = "invalid output table prefix, it can't hold a schema"
+)
+
+// DatabaseConfig tells where a database repository reads the contacts from, a query takes precedence over its table
+type DatabaseConfig struct {
+	ContactsTable string
+	ContactsQuery string
+	// ReferenceTable and ReferenceQuery select the dataset the contacts are linked to, both are empty when deduplicating
+	ReferenceTable string
+	ReferenceQuery string
+	// OutputPrefix is prepended to the name of every output table, e.g. "dedup_" writes the matches to dedup_matches
+	OutputPrefix string
+}
+
+// Linking tells whether a reference dataset is configured
+func (d DatabaseConfig) Linking() bool {
+	return d.ReferenceTable != "" || d.ReferenceQuery != ""
+}
+
+// Validate checks no output table would replace a table the contacts are read from
+func (d DatabaseConfig) Validate() error {
+	if strings.Contains(d.OutputPrefix, ".") {
+		return fmt.Errorf("%s: %q", InvalidOutputPrefixError, d.OutputPrefix)
+	}
+
+	for _, name := range d.outputTables() {
+		for _, input := range []string{d.ContactsTable, d.ReferenceTable} {
+			if input != "" && strings.EqualFold(name, unqualified(input)) {
+				return fmt.Errorf("%s: %q", OutputTableConflictError, input)
+			}
+		}
+	}
+
+	return nil
+}
+
+// outputTables are the names of every output table
+func (d DatabaseConfig) outputTables() []string {
+	return []string{
+		d.OutputPrefix + matchesTable.name, d.OutputPrefix + duplicatesTable.name, d.OutputPrefix + clustersTable.name,
+		d.OutputPrefix + mergedTable.name, d.OutputPrefix + mappingTable.name, d.OutputPrefix + qualityTable.name,
+		d.OutputPrefix + explanationsTable.name,
+	}
+}
+
+// unqualified drops the schema of a table name
+func unqualified(table string) string {
+	return table[strings.LastIndex(table, ".")+1:]
+}
+
+// sourceQuery is the query, or the selection of every row of the table when there is no query
+func sourceQuery(table, query string) (string, error) {
+	if query != "" {
+		return query, nil
+	}
+	if table == "" {
+		return "", errors.New(DatabaseSourceError)
+	}
+	return "SELECT * FROM " + quoteIdentifier(table), nil
+}
+
+// skipNoRows is the invalidRecord of the database repositories, every row has a value for each column so none is skipped
+func skipNoRows(Reject) error {
+	return nil
+}
+
+// outputTable is an output table of the database repositories, replaced on every run and indexed on each group of
+// index columns. row lists the values of a row in column order.
+type outputTable[T any] struct {
+	name    string
+	columns []outputColumn
+	indexes [][]string
+	row     func(T) []any
+}
+
+type outputColumn struct {
+	name     string
+	datatype string
+}
+
+var (
+	matchesTable = outputTable[ProcessOutput]{
+		name: "matches",
+		columns: []outputColumn{
+			{"contact_id_source", "TEXT"}, {"contact_id_match", "TEXT"}, {"accuracy_level", "INTEGER"},
+			{"accuracy", "TEXT"}, {"phonetic_match", "TEXT"}, {"source_side", "TEXT"}, {"match_side", "TEXT"},
+		},
+		indexes: [][]string{{"contact_id_source"}, {"contact_id_match"}},
+		row: func(output ProcessOutput) []any {
+			return []any{
+				output.ContactIDSource, output.ContactIDMatch, output.AccuracyLevel, string(accuracyLabel(output.AccuracyLevel)),
+				output.PhoneticMatch, string(output.SourceSide), string(output.MatchSide),
+			}
+		},
+	}
+	duplicatesTable = outputTable[Contact]{
+		name: "duplicates",
+		columns: []outputColumn{
+			{"contact_id", "TEXT"}, {"first_name", "TEXT"}, {"last_name", "TEXT"},
+			{"email", "TEXT"}, {"zip_code", "TEXT"}, {"address", "TEXT"},
+		},
+		indexes: [][]string{{"contact_id"}},
+		row: func(contact Contact) []any {
+			return []any{contact.ContactID, contact.FirstName, contact.LastName, contact.Email, contact.ZipCode, contact.Address}
+		},
+	}
+	clustersTable = outputTable[ClusterAssignment]{
+		name:    "clusters",
+		columns: []outputColumn{{"contact_id", "TEXT"}, {"cluster_id", "INTEGER"}},
+		indexes: [][]string{{"contact_id"}, {"cluster_id"}},
+		row: func(assignment ClusterAssignment) []any {
+			return []any{assignment.ContactID, assignment.ClusterID}
+		},
+	}
+	mergedTable = outputTable[Contact]{
+		name: "merged_contacts",
+		columns: []outputColumn{
+			{"contact_id", "TEXT"}, {"first_name", "TEXT"}, {"last_name", "TEXT"}, {"email", "TEXT"},
+			{"zip_code", "TEXT"}, {"address", "TEXT"}, {"source", "TEXT"}, {"updated_at", "TEXT"},
+		},
+		indexes: [][]string{{"contact_id"}},
+		row: func(contact Contact) []any {
+			return []any{
+				contact.ContactID, contact.FirstName, contact.LastName, contact.Email,
+				contact.ZipCode, contact.Address, contact.Source, contact.UpdatedAt,
+			}
+		},
+	}
+	mappingTable = outputTable[ContactMapping]{
+		name:    "contact_mapping",
+		columns: []outputColumn{{"contact_id", "TEXT"}, {"surviving_contact_id", "TEXT"}},
+		indexes: [][]string{{"contact_id"}, {"surviving_contact_id"}},
+		row: func(contactMapping ContactMapping) []any {
+			return []any{contactMapping.ContactID, contactMapping.SurvivingContactID}
+		},
+	}
+	qualityTable = outputTable[FieldQuality]{
+		name: "data_quality",
+		columns: []outputColumn{
+			{"field", "TEXT"}, {"values", "INTEGER"}, {"valid", "INTEGER"},
+			{"missing", "INTEGER"}, {"placeholder", "INTEGER"}, {"invalid", "INTEGER"},
+		},
+		row: func(quality FieldQuality) []any {
+			return []any{string(quality.Field), quality.Values, quality.Valid, quality.Missing, quality.Placeholder, quality.Invalid}
+		},
+	}
+	explanationsTable = outputTable[explanationRow]{
+		name: "explanations",
+		columns: []outputColumn{
+			{"contact_id_source", "TEXT"}, {"contact_id_match", "TEXT"}, {"accuracy_level", "INTEGER"},
+			{"accuracy", "TEXT"}, {"field", "TEXT"}, {"match", "TEXT"}, {"method", "TEXT"}, {"score", "DOUBLE PRECISION"},
+			{"source_side", "TEXT"}, {"match_side", "TEXT"},
+		},
+		indexes: [][]string{{"contact_id_source", "contact_id_match"}},
+		row: func(row explanationRow) []any {
+			return []any{
+				row.ContactIDSource, row.ContactIDMatch, row.AccuracyLevel, string(accuracyLabel(row.AccuracyLevel)),
+				string(row.Field), string(row.Match), row.Method, row.Score, string(row.SourceSide), string(row.MatchSide),
+			}
+		},
+	}
+)
+
+// prefixed renames the table with the output prefix of the repository
+func (t outputTable[T]) prefixed(prefix string) outputTable[T] {
+	t.name = prefix + t.name
+	return t
+}
+
+func (t outputTable[T]) drop() string {
+	return "DROP TABLE IF EXISTS " + quoteIdentifier(t.name)
+}
+
+func (t outputTable[T]) create() string {
+	columns := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		columns[i] = quoteIdentifier(column.name) + " " + column.datatype
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(t.name), strings.Join(columns, ", "))
+}
+
+func (t outputTable[T]) columnNames() []string {
+	names := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// createIndexes names every index after its table and columns, e.g. matches_contact_id_source
+func (t outputTable[T]) createIndexes() []string {
+	statements := make([]string, len(t.indexes))
+	for i, columns := range t.indexes {
+		quoted := make([]string, len(columns))
+		for j, column := range columns {
+			quoted[j] = quoteIdentifier(column)
+		}
+		name := t.name + "_" + strings.Join(columns, "_")
+		statements[i] = fmt.Sprintf("CREATE INDEX %s ON %s (%s)", quoteIdentifier(name), quoteIdentifier(t.name), strings.Join(quoted, ", "))
+	}
+	return statements
+}
+
+// quoteIdentifier quotes every part of a dotted name, so a table can be qualified by its schema
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// accuracyLabel names the accuracy level, levels out of range have no name
+func accuracyLabel(level int) Accuracy {
+	accuracy, _ := MapLevelToAccuracy(level)
+	return accuracy
+}
@@ -0,0 +1,33 @@
+package contact_test
+
+import (
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseConfig_Validate(t *testing.T) {
+	t.Run("when no output table is read as input, it should return no error", func(t *testing.T) {
+		config := contact.DatabaseConfig{ContactsTable: "contacts", ReferenceTable: "crm.matches", OutputPrefix: "dedup_"}
+
+		assert.Nil(t, config.Validate())
+	})
+
+	t.Run("when an output table is the contacts or the reference table, it should return an error", func(t *testing.T) {
+		err := contact.DatabaseConfig{ContactsTable: "Clusters"}.Validate()
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.OutputTableConflictError+`: "Clusters"`, err.Error())
+
+		err = contact.DatabaseConfig{ContactsTable: "contacts", ReferenceTable: "public.dedup_matches", OutputPrefix: "dedup_"}.Validate()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.OutputTableConflictError)
+	})
+
+	t.Run("when the output prefix holds a schema, it should return an error", func(t *testing.T) {
+		err := contact.DatabaseConfig{ContactsTable: "contacts", OutputPrefix: "dedup."}.Validate()
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidOutputPrefixError)
+	})
+}
@@ -0,0 +1,42 @@
+package contact
+
+import "strings"
+
+// emailDomainAliases maps domains to the provider domain they are an alias of
+var emailDomainAliases = map[string]string{
+	"googlemail.com": "gmail.com",
+	"me.com":         "icloud.com",
+	"mac.com":        "icloud.com",
+}
+
+// dotInsensitiveDomains ignore dots in the local part, so "john.doe" and "johndoe" are the same mailbox
+var dotInsensitiveDomains = map[string]bool{
+	"gmail.com": true,
+}
+
+// CanonicalEmail folds the email to the mailbox it delivers to, e.g. " John.Doe+crm@GoogleMail.com" -> "johndoe@gmail.com".
+// It trims and lower cases the address, resolves domain aliases, strips plus tags and applies the provider dot rules.
+func CanonicalEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+
+	local, domain := email[:at], email[at+1:]
+
+	if alias, ok := emailDomainAliases[domain]; ok {
+		domain = alias
+	}
+
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+
+	if dotInsensitiveDomains[domain] {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+
+	return local + "@" + domain
+}
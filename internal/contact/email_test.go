@@ -0,0 +1,29 @@
+package contact_test
+
+import (
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalEmail(t *testing.T) {
+	t.Run("when the email has casing, spaces and a plus tag, it should fold them", func(t *testing.T) {
+		assert.Equal(t, "john.doe@example.com", contact.CanonicalEmail("  John.Doe+crm@Example.COM "))
+	})
+
+	t.Run("when the email is from a gmail style domain, it should remove the dots of the local part", func(t *testing.T) {
+		assert.Equal(t, "johndoe@gmail.com", contact.CanonicalEmail("John.Doe+crm@Gmail.com"))
+		assert.Equal(t, "johndoe@gmail.com", contact.CanonicalEmail("johndoe@gmail.com"))
+	})
+
+	t.Run("when the domain is a known alias, it should use the provider domain", func(t *testing.T) {
+		assert.Equal(t, "johndoe@gmail.com", contact.CanonicalEmail("john.doe@googlemail.com"))
+		assert.Equal(t, "john.doe@icloud.com", contact.CanonicalEmail("john.doe@me.com"))
+	})
+
+	t.Run("when the value is not an email, it should only trim and lower case it", func(t *testing.T) {
+		assert.Equal(t, "n/a", contact.CanonicalEmail(" N/A "))
+		assert.Equal(t, "", contact.CanonicalEmail(""))
+	})
+}
@@ -0,0 +1,25 @@
+package contact
+
+// FieldMatch is the reason a field of two contacts got credit
+type FieldMatch string
+
+const (
+	ExactFieldMatch             FieldMatch = "exact"
+	SimilarFieldMatch           FieldMatch = "similar"
+	PhoneticFieldMatch          FieldMatch = "phonetic"
+	NicknameFieldMatch          FieldMatch = "nickname"
+	FirstLetterFieldMatch       FieldMatch = "first_letter"
+	AddressComponentsFieldMatch FieldMatch = "address_components"
+	NoFieldMatch                FieldMatch = "none"
+	MissingFieldMatch           FieldMatch = "missing"
+)
+
+// FieldExplanation is the part of a match score given by one field
+type FieldExplanation struct {
+	Field Field      `json:"field"`
+	Match FieldMatch `json:"match"`
+	// Method is the comparator or phonetic encoder that recognised the values as alike
+	Method string `json:"method,omitempty"`
+	// Score is the weighted credit of the field, or its agreement weight in bits with the Fellegi-Sunter model
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,25 @@
+package contact
+
+import "context"
+
+// PrepareScoring blocks and prepares the contacts once and returns a function scoring their candidate pairs on the
+// given number of workers, so the benchmarks only time the scoring
+func PrepareScoring(contacts []Contact, config Config) (func(ctx context.Context, workers int) error, error) {
+	blocker, err := newBlocker(config.BlockKeys, config.MaxBlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newScorer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	records := s.prepareAll(contacts)
+	pairs := uniquePairs(records, blocker.candidatePairs(contacts, 0))
+
+	return func(ctx context.Context, workers int) error {
+		_, err := scorePairs(ctx, s, records, pairs, workers, scoreProgress{})
+		return err
+	}, nil
+}
@@ -0,0 +1,441 @@
+package contact
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+const (
+	UnknownScoringModelError  = "unknown scoring model"
+	InvalidFellegiSunterError = "invalid fellegi-sunter config"
+)
+
+type ScoringModel string
+
+const (
+	// HeuristicModel adds a point per matching field with partial credit for similar values
+	HeuristicModel ScoringModel = "heuristic"
+	// FellegiSunterModel weighs the agreement of each field by its m and u probabilities estimated with EM
+	FellegiSunterModel ScoringModel = "fellegi_sunter"
+)
+
+// Agreement of a field between the two compared contacts
+const (
+	fieldMissing = iota
+	fieldDisagrees
+	fieldAgrees
+)
+
+// comparedFields are the fields of the agreement pattern, in pattern order
+var comparedFields = [...]Field{FirstNameField, LastNameField, EmailField, ZipCodeField, AddressField}
+
+// fellegiSunterCutoffs are the match probabilities from which a pair reaches each accuracy level when none are configured
+var fellegiSunterCutoffs = AccuracyCutoffs{0.5, 0.65, 0.8, 0.95, 0.99}
+
+// Probabilities are kept away from 0 and 1 so every agreement weight is finite
+const (
+	minProbability = 1e-6
+	maxProbability = 1 - minProbability
+)
+
+// FellegiSunterConfig tunes the estimation of the m and u probabilities
+type FellegiSunterConfig struct {
+	// MaxIterations stops EM when it didn't converge, 0 uses 100
+	MaxIterations int `json:"max_iterations"`
+	// Tolerance is the largest change of any probability between iterations at which EM converged, 0 uses 1e-6
+	Tolerance float64 `json:"tolerance"`
+	// MatchPrior is the initial share of candidate pairs that are matches, 0 uses 0.01
+	MatchPrior float64 `json:"match_prior"`
+	// Cutoffs are the match probabilities from which a pair reaches each accuracy level, empty uses 0.5, 0.65, 0.8, 0.95 and 0.99
+	Cutoffs AccuracyCutoffs `json:"cutoffs"`
+}
+
+func (f FellegiSunterConfig) validate() error {
+	if f.MaxIterations < 0 {
+		return fmt.Errorf("%s: max iterations %d, it must be 0 or greater", InvalidFellegiSunterError, f.MaxIterations)
+	}
+
+	if f.Tolerance < 0 || f.Tolerance >= 1 {
+		return fmt.Errorf("%s: tolerance %v, it must be at least 0 and lower than 1", InvalidFellegiSunterError, f.Tolerance)
+	}
+
+	if f.MatchPrior < 0 || f.MatchPrior >= 1 {
+		return fmt.Errorf("%s: match prior %v, it must be at least 0 and lower than 1", InvalidFellegiSunterError, f.MatchPrior)
+	}
+
+	for _, cutoff := range f.Cutoffs {
+		if cutoff > 1 {
+			return fmt.Errorf("%s: cutoff %v, match probabilities are at most 1", InvalidFellegiSunterError, cutoff)
+		}
+	}
+
+	return f.Cutoffs.validate()
+}
+
+func validateScoringModel(model ScoringModel) error {
+	switch model {
+	case "", HeuristicModel, FellegiSunterModel:
+		return nil
+	default:
+		return fmt.Errorf("%s: %q", UnknownScoringModelError, model)
+	}
+}
+
+// agreementPattern holds the agreement of every compared field, as a base 3 number with a digit per field
+type agreementPattern int
+
+const agreementPatterns = 243 // 3^len(comparedFields)
+
+func (p agreementPattern) field(index int) int {
+	for range index {
+		p /= 3
+	}
+	return int(p % 3)
+}
+
+// fieldProbabilities are the probabilities of a field agreeing among matches (m) and among non-matches (u)
+type fieldProbabilities struct {
+	m, u float64
+}
+
+// FellegiSunterEstimate is the match prior and the m and u probabilities of every compared field estimated by EM.
+// The match index keeps it, so incremental runs score with the model estimated on every contact.
+type FellegiSunterEstimate struct {
+	Prior         float64                      `json:"prior"`
+	Probabilities map[Field]FieldProbabilities `json:"probabilities"`
+}
+
+// FieldProbabilities are the estimated probabilities of a field agreeing among matches (M) and among non-matches (U)
+type FieldProbabilities struct {
+	M float64 `json:"m"`
+	U float64 `json:"u"`
+}
+
+// fellegiSunterScorer turns the agreement pattern of a pair into a match probability and its accuracy level
+type fellegiSunterScorer struct {
+	scorer
+	cutoffs       AccuracyCutoffs
+	prior         float64
+	probabilities [len(comparedFields)]fieldProbabilities
+	// matchProbability is precomputed for every agreement pattern
+	matchProbability [agreementPatterns]float64
+}
+
+// agreement compares each field of both contacts: a field agrees when it is an exact match or similar enough to get credit
+// from the heuristic scorer, names also agree when they share the initial of a single letter name.
+// A field is missing when either contact has no value.
+// When explanations isn't nil it is filled with the reason each field agrees, scores are left to the caller.
+func (s scorer) agreement(r1, r2 record, explanations []FieldExplanation) (agreementPattern, comparison) {
+	fieldsToCompare := [...]fieldValues{
+		{field: FirstNameField, value1: r1.firstName, value2: r2.firstName},
+		{field: LastNameField, value1: r1.lastName, value2: r2.lastName},
+		{field: EmailField, value1: r1.email, value2: r2.email},
+		{field: ZipCodeField, value1: r1.zipCode, value2: r2.zipCode},
+		{field: AddressField, value1: r1.address, value2: r2.address},
+	}
+
+	var pattern agreementPattern
+	var phoneticMatches []string
+	exactMatches := 0
+	place := agreementPattern(1)
+
+	for i, fields := range fieldsToCompare {
+		agreement := fieldDisagrees
+		explanation := FieldExplanation{Field: fields.field, Match: NoFieldMatch}
+		switch {
+		case fields.value1 == "" || fields.value2 == "":
+			agreement = fieldMissing
+			explanation.Match = MissingFieldMatch
+		case len(fields.value1) == 1 || len(fields.value2) == 1:
+			// A single letter is an initial, it still tells names apart
+			if isNameField(fields.field) && strings.EqualFold(fields.value1[:1], fields.value2[:1]) {
+				agreement = fieldAgrees
+				explanation.Match = FirstLetterFieldMatch
+			}
+		case fields.value1 == fields.value2:
+			agreement = fieldAgrees
+			explanation.Match = ExactFieldMatch
+			exactMatches++
+		case s.compareSimilarity(fields) > 0:
+			agreement = fieldAgrees
+			explanation.Match, explanation.Method = SimilarFieldMatch, string(s.comparators[fields.field].Comparator)
+		case fields.field == FirstNameField && s.nicknames.Equivalent(fields.value1, fields.value2):
+			agreement = fieldAgrees
+			explanation.Match = NicknameFieldMatch
+		case isNameField(fields.field) && len(s.phoneticEncoders) > 0:
+			keys1, keys2 := r1.firstNameKeys, r2.firstNameKeys
+			if fields.field == LastNameField {
+				keys1, keys2 = r1.lastNameKeys, r2.lastNameKeys
+			}
+
+			if encoder, ok := matchPhonetic(s.phoneticEncoders, keys1, keys2); ok {
+				agreement = fieldAgrees
+				explanation.Match, explanation.Method = PhoneticFieldMatch, string(encoder)
+				phoneticMatches = append(phoneticMatches, fmt.Sprintf("%s:%s", fields.field, encoder))
+			}
+		}
+
+		if explanations != nil {
+			explanations[i] = explanation
+		}
+
+		pattern += agreementPattern(agreement) * place
+		place *= 3
+	}
+
+	return pattern, comparison{
+		duplicate:     exactMatches == len(fieldsToCompare),
+		phoneticMatch: strings.Join(phoneticMatches, ";"),
+	}
+}
+
+func (f *fellegiSunterScorer) score(r1, r2 record) comparison {
+	pattern, result := f.agreement(r1, r2, nil)
+	if result.duplicate {
+		return comparison{duplicate: true}
+	}
+
+	result.accuracyLevel = f.cutoffs.level(f.matchProbability[pattern])
+	if result.accuracyLevel == 0 {
+		result.phoneticMatch = ""
+	}
+	return result
+}
+
+// explain gives the reason each field agrees, scored by its agreement or disagreement weight in bits
+func (f *fellegiSunterScorer) explain(r1, r2 record) []FieldExplanation {
+	explanations := make([]FieldExplanation, len(comparedFields))
+	pattern, _ := f.agreement(r1, r2, explanations)
+
+	for k, probabilities := range f.probabilities {
+		switch pattern.field(k) {
+		case fieldAgrees:
+			explanations[k].Score = math.Log2(probabilities.m / probabilities.u)
+		case fieldDisagrees:
+			explanations[k].Score = math.Log2((1 - probabilities.m) / (1 - probabilities.u))
+		}
+	}
+
+	return explanations
+}
+
+// newFellegiSunterScorer estimates the m and u probabilities of every field with EM over the agreement patterns of the candidate pairs
+func newFellegiSunterScorer(ctx context.Context, s scorer, config FellegiSunterConfig, records []record, pairs []pair, workers int) (*fellegiSunterScorer, error) {
+	counts, err := countPatterns(ctx, s, records, pairs, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &fellegiSunterScorer{scorer: s, cutoffs: config.Cutoffs}
+	if len(model.cutoffs) == 0 {
+		model.cutoffs = fellegiSunterCutoffs
+	}
+	model.estimate(counts, config)
+	model.precompute()
+
+	return model, nil
+}
+
+// restoreFellegiSunterScorer scores with the probabilities of a previous estimate instead of estimating them again
+func restoreFellegiSunterScorer(s scorer, config FellegiSunterConfig, estimate FellegiSunterEstimate) (*fellegiSunterScorer, error) {
+	model := &fellegiSunterScorer{scorer: s, cutoffs: config.Cutoffs, prior: clampProbability(estimate.Prior)}
+	if len(model.cutoffs) == 0 {
+		model.cutoffs = fellegiSunterCutoffs
+	}
+
+	for k, field := range comparedFields {
+		probabilities, ok := estimate.Probabilities[field]
+		if !ok {
+			return nil, fmt.Errorf("%s: no estimated probabilities for %s", InvalidFellegiSunterError, field)
+		}
+		model.probabilities[k] = fieldProbabilities{m: clampProbability(probabilities.M), u: clampProbability(probabilities.U)}
+	}
+	model.precompute()
+
+	return model, nil
+}
+
+// estimated returns the prior and probabilities the model scores with
+func (f *fellegiSunterScorer) estimated() FellegiSunterEstimate {
+	estimate := FellegiSunterEstimate{Prior: f.prior, Probabilities: make(map[Field]FieldProbabilities, len(comparedFields))}
+	for k, probabilities := range f.probabilities {
+		estimate.Probabilities[comparedFields[k]] = FieldProbabilities{M: probabilities.m, U: probabilities.u}
+	}
+	return estimate
+}
+
+// countPatterns counts the agreement patterns of the candidate pairs, duplicates are left out since they are certain matches
+func countPatterns(ctx context.Context, s scorer, records []record, pairs []pair, workers int) ([agreementPatterns]int, error) {
+	var counts [agreementPatterns]int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	chunks := make(chan []pair)
+	for range max(1, workers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local [agreementPatterns]int
+			for chunk := range chunks {
+				for _, p := range chunk {
+					pattern, result := s.agreement(records[p.i], records[p.j], nil)
+					if !result.duplicate {
+						local[pattern]++
+					}
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for pattern, count := range local {
+				counts[pattern] += count
+			}
+		}()
+	}
+
+	var err error
+	for start := 0; start < len(pairs) && err == nil; start += pairsPerChunk {
+		select {
+		case chunks <- pairs[start:min(start+pairsPerChunk, len(pairs))]:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	return counts, err
+}
+
+// estimate runs EM from the configured prior, starting every field with m = 0.9 and u = its agreement rate
+func (f *fellegiSunterScorer) estimate(counts [agreementPatterns]int, config FellegiSunterConfig) {
+	maxIterations := config.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 100
+	}
+	tolerance := config.Tolerance
+	if tolerance == 0 {
+		tolerance = 1e-6
+	}
+	f.prior = config.MatchPrior
+	if f.prior == 0 {
+		f.prior = 0.01
+	}
+
+	for k := range comparedFields {
+		var agree, observed int
+		for pattern, count := range counts {
+			switch agreementPattern(pattern).field(k) {
+			case fieldAgrees:
+				agree += count
+				observed += count
+			case fieldDisagrees:
+				observed += count
+			}
+		}
+
+		f.probabilities[k] = fieldProbabilities{m: 0.9, u: 0.1}
+		if observed > 0 {
+			f.probabilities[k].u = clampProbability(math.Min(float64(agree)/float64(observed), 0.5))
+		}
+	}
+
+	for range maxIterations {
+		var total, matches float64
+		var agreeMatches, observedMatches, agreeNonMatches, observedNonMatches [len(comparedFields)]float64
+
+		// E step: the probability of each pattern being a match, M step: the expected agreement rates among matches and non-matches
+		for pattern, count := range counts {
+			if count == 0 {
+				continue
+			}
+
+			weight := float64(count)
+			posterior := f.posterior(agreementPattern(pattern))
+			total += weight
+			matches += weight * posterior
+
+			for k := range comparedFields {
+				switch agreementPattern(pattern).field(k) {
+				case fieldAgrees:
+					agreeMatches[k] += weight * posterior
+					agreeNonMatches[k] += weight * (1 - posterior)
+					fallthrough
+				case fieldDisagrees:
+					observedMatches[k] += weight * posterior
+					observedNonMatches[k] += weight * (1 - posterior)
+				}
+			}
+		}
+
+		if total == 0 {
+			return
+		}
+
+		prior := clampProbability(matches / total)
+		change := math.Abs(prior - f.prior)
+		f.prior = prior
+
+		for k := range comparedFields {
+			probabilities := f.probabilities[k]
+			if observedMatches[k] > 0 {
+				probabilities.m = clampProbability(agreeMatches[k] / observedMatches[k])
+			}
+			if observedNonMatches[k] > 0 {
+				probabilities.u = clampProbability(agreeNonMatches[k] / observedNonMatches[k])
+			}
+
+			change = math.Max(change, math.Abs(probabilities.m-f.probabilities[k].m))
+			change = math.Max(change, math.Abs(probabilities.u-f.probabilities[k].u))
+			f.probabilities[k] = probabilities
+		}
+
+		if change < tolerance {
+			return
+		}
+	}
+}
+
+// posterior is the probability of a pair with the agreement pattern being a match, under conditional independence of the fields
+func (f *fellegiSunterScorer) posterior(pattern agreementPattern) float64 {
+	logOdds := math.Log(f.prior) - math.Log(1-f.prior) + f.matchWeight(pattern)
+	return 1 / (1 + math.Exp(-logOdds))
+}
+
+// matchWeight is the log-likelihood ratio of the pattern among matches versus non-matches, missing fields weigh nothing
+func (f *fellegiSunterScorer) matchWeight(pattern agreementPattern) float64 {
+	var weight float64
+	for k, probabilities := range f.probabilities {
+		switch pattern.field(k) {
+		case fieldAgrees:
+			weight += math.Log(probabilities.m / probabilities.u)
+		case fieldDisagrees:
+			weight += math.Log((1 - probabilities.m) / (1 - probabilities.u))
+		}
+	}
+	return weight
+}
+
+func (f *fellegiSunterScorer) precompute() {
+	for pattern := range agreementPatterns {
+		f.matchProbability[pattern] = f.posterior(agreementPattern(pattern))
+	}
+}
+
+// describe lists the estimated probabilities and the agreement weights in bits of every field
+func (f *fellegiSunterScorer) describe() string {
+	parts := []string{fmt.Sprintf("match prior %.4f", f.prior)}
+	for k, probabilities := range f.probabilities {
+		parts = append(parts, fmt.Sprintf("%s m=%.4f u=%.4f agree=%+.2f disagree=%+.2f",
+			comparedFields[k], probabilities.m, probabilities.u,
+			math.Log2(probabilities.m/probabilities.u), math.Log2((1-probabilities.m)/(1-probabilities.u))))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func clampProbability(probability float64) float64 {
+	return math.Min(math.Max(probability, minProbability), maxProbability)
+}
@@ -0,0 +1,124 @@
+package contact_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContactService_EvaluateFellegiSunter(t *testing.T) {
+	logger := logrus.New()
+	firstNames := []string{"Deacon", "Linda", "Carly", "Callie", "Desirae", "Wallace", "Mia", "Isaiah", "Freya", "Abigail"}
+	lastNames := []string{"Hutchinson", "Crane", "Foreman", "Sosa", "Tucker", "Becker", "Baxter", "Fischer", "West", "Boone"}
+	streets := []string{"In", "Rutrum", "Nulla", "Dictum", "Sed", "Tempor", "Aliquam", "Non", "Arcu", "Lacus"}
+
+	// Every contact is unique except the last ten, that repeat the first ten with initials and a different email provider
+	var mockContacts []contact.Contact
+	for i := range 60 {
+		mockContacts = append(mockContacts, contact.Contact{
+			ContactID: fmt.Sprint(i + 1),
+			FirstName: firstNames[i%10],
+			LastName:  lastNames[(i/10+i)%10],
+			Email:     fmt.Sprintf("user%d@outlook.com", i),
+			ZipCode:   fmt.Sprint(10000 + i),
+			Address:   fmt.Sprintf("%d %s St.", 100+i, streets[(i/10)%10]),
+		})
+	}
+	for i := range 10 {
+		original := mockContacts[i]
+		mockContacts = append(mockContacts, contact.Contact{
+			ContactID: fmt.Sprint(61 + i),
+			FirstName: original.FirstName[:1],
+			LastName:  original.LastName[:1],
+			Email:     fmt.Sprintf("user%d@yahoo.com", i),
+			ZipCode:   original.ZipCode,
+			Address:   original.Address,
+		})
+	}
+
+	config := contact.Config{
+		ScoringModel: contact.FellegiSunterModel,
+		Comparators: map[contact.Field]contact.FieldComparator{
+			contact.FirstNameField: {Comparator: contact.JaroWinklerComparator, Threshold: 0.9},
+			contact.LastNameField:  {Comparator: contact.JaroWinklerComparator, Threshold: 0.9},
+		},
+		Workers: 2,
+	}
+
+	t.Run("when scoring with the Fellegi-Sunter model, it should match the records that agree on the most informative fields", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 20, len(results))
+		for i := 0; i < len(results); i += 2 {
+			source, match := results[i].ContactIDSource, results[i].ContactIDMatch
+			assert.Equal(t, source, fmt.Sprint(atoi(t, match)-60))
+			assert.Equal(t, 5, results[i].AccuracyLevel)
+		}
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when explanations are enabled, it should weigh each field by its agreement weight", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteExplanations", mock.Anything, mock.Anything).Return(nil)
+
+		explained := config
+		explained.Explain = true
+		results, err := collect(contact.NewContactService(logger, mockRepo, explained).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 20, len(results))
+		explanation := results[0].Explanation
+		assert.Equal(t, 5, len(explanation))
+		assert.Equal(t, contact.FirstLetterFieldMatch, explanation[0].Match)
+		assert.Equal(t, contact.NoFieldMatch, explanation[2].Match)
+		assert.Equal(t, contact.ExactFieldMatch, explanation[3].Match)
+		assert.Greater(t, explanation[3].Score, 0.0)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the scoring model is unknown, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{ScoringModel: "unknown"}).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.UnknownScoringModelError)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the match prior is not a probability, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		invalid := contact.Config{
+			ScoringModel:  contact.FellegiSunterModel,
+			FellegiSunter: contact.FellegiSunterConfig{MatchPrior: 1.5},
+		}
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, invalid).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidFellegiSunterError)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func atoi(t *testing.T, value string) int {
+	var number int
+	_, err := fmt.Sscan(value, &number)
+	assert.Nil(t, err)
+	return number
+}
@@ -0,0 +1,264 @@
+package contact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	IndexMismatchError = "match index does not match the current config"
+	IndexRequiredError = "incremental matching requires a match index"
+	IndexNotFoundError = "match index not found, run once without incremental matching to build it"
+	IndexLinkageError  = "match index is not supported when linking two datasets"
+)
+
+// MatchIndex is the state of a previous run that new and changed contacts are matched against:
+// every contact with its blocks, its normalized fields and its cluster.
+// The options that shape the stored fields are kept to check the index still fits the config.
+type MatchIndex struct {
+	BlockKeys        []string          `json:"block_keys"`
+	NormalizeEmails  bool              `json:"normalize_emails"`
+	ParseAddresses   bool              `json:"parse_addresses"`
+	PhoneticEncoders []PhoneticEncoder `json:"phonetic_encoders"`
+	Validation       ValidationConfig  `json:"validation"`
+	// FellegiSunter is the model estimated on every contact, nil when the run used the heuristic model
+	FellegiSunter *FellegiSunterEstimate `json:"fellegi_sunter,omitempty"`
+	Entries       []IndexEntry           `json:"entries"`
+}
+
+// IndexEntry is an indexed contact
+type IndexEntry struct {
+	Contact Contact `json:"contact"`
+	// Checksum identifies the values of the contact, a changed contact gets a different checksum
+	Checksum string `json:"checksum"`
+	// ClusterID is 0 when the run didn't cluster the contacts
+	ClusterID int `json:"cluster_id"`
+	// Blocks holds the block of the contact for each block key, in block key order
+	Blocks []string `json:"blocks,omitempty"`
+	// Invalid lists the fields dropped by validation, they are compared as missing
+	Invalid       []Field                      `json:"invalid,omitempty"`
+	Email         string                       `json:"email"`
+	Address       string                       `json:"address"`
+	ParsedAddress Address                      `json:"parsed_address"`
+	FirstNameKeys map[PhoneticEncoder][]string `json:"first_name_keys,omitempty"`
+	LastNameKeys  map[PhoneticEncoder][]string `json:"last_name_keys,omitempty"`
+}
+
+// IndexStore persists the match index between runs
+type IndexStore interface {
+	// LoadIndex reports false when there is no index yet
+	LoadIndex(ctx context.Context) (MatchIndex, bool, error)
+	SaveIndex(ctx context.Context, index MatchIndex) error
+}
+
+type indexStore struct {
+	json      pkg.JSONConnector
+	indexPath string
+}
+
+// NewIndexStore keeps the match index as JSON in indexPath
+func NewIndexStore(json pkg.JSONConnector, indexPath string) IndexStore {
+	return &indexStore{
+		json:      json,
+		indexPath: indexPath,
+	}
+}
+
+func (i indexStore) LoadIndex(ctx context.Context) (MatchIndex, bool, error) {
+	var index MatchIndex
+	err := i.json.ReadJSON(ctx, i.indexPath, &index)
+	if errors.Is(err, os.ErrNotExist) {
+		return MatchIndex{}, false, nil
+	}
+	if err != nil {
+		return MatchIndex{}, false, err
+	}
+
+	return index, true, nil
+}
+
+func (i indexStore) SaveIndex(ctx context.Context, index MatchIndex) error {
+	return i.json.WriteJSON(ctx, i.indexPath, index)
+}
+
+func validateIndex(config Config) error {
+	if config.Incremental && config.Index == nil {
+		return errors.New(IndexRequiredError)
+	}
+
+	if config.Index != nil && config.Linkage {
+		return errors.New(IndexLinkageError)
+	}
+
+	return nil
+}
+
+// validate checks the stored fields were computed with the same options as the current run
+func (m MatchIndex) validate(config Config) error {
+	if !slices.Equal(m.BlockKeys, config.BlockKeys) {
+		return fmt.Errorf("%s: block keys %v, the index has %v", IndexMismatchError, config.BlockKeys, m.BlockKeys)
+	}
+
+	if m.NormalizeEmails != config.NormalizeEmails || m.ParseAddresses != config.ParseAddresses ||
+		!slices.Equal(m.PhoneticEncoders, config.PhoneticEncoders) || !m.Validation.equal(config.Validation) {
+		return fmt.Errorf("%s: the normalization options changed", IndexMismatchError)
+	}
+
+	for i, entry := range m.Entries {
+		if len(entry.Blocks) != len(m.BlockKeys) {
+			return fmt.Errorf("%s: entry %d has %d blocks", IndexMismatchError, i, len(entry.Blocks))
+		}
+	}
+
+	return nil
+}
+
+// indexedContacts are the contacts of an incremental run: the indexed contacts that didn't change,
+// followed by the new and changed contacts from base
+type indexedContacts struct {
+	contacts  []Contact
+	records   []record
+	blockKeys [][]string
+	base      int
+	// clusters holds the previous cluster of the indexed contacts
+	clusters []int
+	// estimate is the Fellegi-Sunter model of the index, nil with the heuristic model
+	estimate *FellegiSunterEstimate
+}
+
+// loadIndexedContacts restores the indexed contacts that are still in the input and appends the input contacts that are
+// not in the index or changed
+func (c contactService) loadIndexedContacts(ctx context.Context, b blocker, s scorer, input []Contact) (indexedContacts, error) {
+	index, found, err := c.config.Index.LoadIndex(ctx)
+	if err != nil {
+		return indexedContacts{}, err
+	}
+	if !found {
+		return indexedContacts{}, errors.New(IndexNotFoundError)
+	}
+
+	if err = index.validate(c.config); err != nil {
+		return indexedContacts{}, err
+	}
+
+	// The probabilities estimated on the new and changed contacts alone would not fit the rest
+	if c.config.ScoringModel == FellegiSunterModel && index.FellegiSunter == nil {
+		return indexedContacts{}, fmt.Errorf("%s: the index has no fellegi-sunter estimate, run once without incremental matching",
+			IndexMismatchError)
+	}
+
+	checksums := make(map[string]string, len(index.Entries))
+	for _, entry := range index.Entries {
+		checksums[entry.Contact.ContactID] = entry.Checksum
+	}
+
+	var changed []Contact
+	changedIDs := make(map[string]bool)
+	inputIDs := make(map[string]bool, len(input))
+	for _, contact := range input {
+		inputIDs[contact.ContactID] = true
+		if checksum, ok := checksums[contact.ContactID]; ok && checksum == contactChecksum(contact) {
+			continue
+		}
+		changed = append(changed, contact)
+		changedIDs[contact.ContactID] = true
+	}
+
+	indexed := indexedContacts{estimate: index.FellegiSunter}
+	removed := 0
+	for _, entry := range index.Entries {
+		if !inputIDs[entry.Contact.ContactID] {
+			removed++
+			continue
+		}
+		if changedIDs[entry.Contact.ContactID] {
+			continue
+		}
+		indexed.contacts = append(indexed.contacts, entry.Contact)
+		indexed.records = append(indexed.records, entry.record())
+		indexed.blockKeys = append(indexed.blockKeys, entry.Blocks)
+		indexed.clusters = append(indexed.clusters, entry.ClusterID)
+	}
+
+	if removed > 0 {
+		c.log.Infof("removed %d indexed contacts that are no longer in the input", removed)
+	}
+
+	indexed.base = len(indexed.contacts)
+	indexed.contacts = append(indexed.contacts, changed...)
+	indexed.records = append(indexed.records, s.prepareAll(changed)...)
+	indexed.blockKeys = append(indexed.blockKeys, b.blockKeys(changed)...)
+
+	return indexed, nil
+}
+
+// buildIndex indexes every contact of the run with its cluster and the model it was scored with,
+// assignments are empty when the run didn't cluster
+func (c contactService) buildIndex(records []record, blockKeys [][]string, assignments []ClusterAssignment, model pairScorer) MatchIndex {
+	index := MatchIndex{
+		BlockKeys:        c.config.BlockKeys,
+		NormalizeEmails:  c.config.NormalizeEmails,
+		ParseAddresses:   c.config.ParseAddresses,
+		PhoneticEncoders: c.config.PhoneticEncoders,
+		Validation:       c.config.Validation,
+		Entries:          make([]IndexEntry, len(records)),
+	}
+	if model, ok := model.(*fellegiSunterScorer); ok {
+		estimate := model.estimated()
+		index.FellegiSunter = &estimate
+	}
+
+	for i, r := range records {
+		index.Entries[i] = IndexEntry{
+			Contact:       r.Contact,
+			Checksum:      contactChecksum(r.Contact),
+			Blocks:        blockKeys[i],
+			Invalid:       r.invalid,
+			Email:         r.email,
+			Address:       r.address,
+			ParsedAddress: r.parsedAddress,
+			FirstNameKeys: r.firstNameKeys,
+			LastNameKeys:  r.lastNameKeys,
+		}
+		if assignments != nil {
+			index.Entries[i].ClusterID = assignments[i].ClusterID
+		}
+	}
+
+	return index
+}
+
+// record restores the prepared contact from its stored fields
+func (e IndexEntry) record() record {
+	compared := dropInvalid(e.Contact, e.Invalid)
+	return record{
+		Contact:       e.Contact,
+		invalid:       e.Invalid,
+		firstName:     compared.FirstName,
+		lastName:      compared.LastName,
+		zipCode:       compared.ZipCode,
+		email:         e.Email,
+		address:       e.Address,
+		parsedAddress: e.ParsedAddress,
+		firstNameKeys: e.FirstNameKeys,
+		lastNameKeys:  e.LastNameKeys,
+	}
+}
+
+// contactChecksum is the SHA-256 of every value of the contact
+func contactChecksum(contact Contact) string {
+	values := []string{
+		contact.ContactID, contact.FirstName, contact.LastName, contact.Email,
+		contact.ZipCode, contact.Address, contact.Source, contact.UpdatedAt,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,200 @@
+package contact_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContactService_EvaluateIncremental(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+	mockContacts := generatedContacts(120)
+	indexed, added := mockContacts[:100], mockContacts[100:]
+
+	// evaluate runs the service on the contacts, returning the matches and the clusters it wrote
+	evaluate := func(t *testing.T, contacts []contact.Contact, config contact.Config) ([]contact.ProcessOutput, []contact.ClusterAssignment) {
+		var clusters []contact.ClusterAssignment
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(contacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteClusters", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			clusters = args.Get(1).([]contact.ClusterAssignment)
+		}).Return(nil)
+		mockRepo.On("WriteMergedContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteContactMapping", mock.Anything, mock.Anything).Return(nil)
+
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(ctx))
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+		return results, clusters
+	}
+
+	newConfig := func(store contact.IndexStore, incremental bool) contact.Config {
+		config := contact.DefaultConfig()
+		config.Index = store
+		config.Incremental = incremental
+		return config
+	}
+
+	// involving keeps the matches of the given contacts
+	involving := func(results []contact.ProcessOutput, contacts []contact.Contact) []contact.ProcessOutput {
+		var filtered []contact.ProcessOutput
+		for _, result := range results {
+			if slices.ContainsFunc(contacts, func(c contact.Contact) bool {
+				return c.ContactID == result.ContactIDSource || c.ContactID == result.ContactIDMatch
+			}) {
+				filtered = append(filtered, result)
+			}
+		}
+		return filtered
+	}
+
+	t.Run("when new contacts are added, it should only write their matches and keep the clusters of a full run", func(t *testing.T) {
+		store := contact.NewIndexStore(pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "index.json"))
+		evaluate(t, indexed, newConfig(store, false))
+
+		results, clusters := evaluate(t, mockContacts, newConfig(store, true))
+		fullResults, fullClusters := evaluate(t, mockContacts, newConfig(nil, false))
+
+		assert.NotEmpty(t, results)
+		assert.Equal(t, involving(fullResults, added), results)
+		assert.Equal(t, fullClusters, clusters)
+
+		// The index now holds every contact, a run without changes finds no new matches
+		results, clusters = evaluate(t, mockContacts, newConfig(store, true))
+		assert.Empty(t, results)
+		assert.Equal(t, fullClusters, clusters)
+	})
+
+	t.Run("when blocking, it should pair the new contacts with the indexed ones sharing a stored block", func(t *testing.T) {
+		store := contact.NewIndexStore(pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "index.json"))
+		blocked := func(store contact.IndexStore, incremental bool) contact.Config {
+			config := newConfig(store, incremental)
+			config.BlockKeys = []string{contact.ZipBlockKey, contact.LastSoundexBlockKey}
+			return config
+		}
+		evaluate(t, indexed, blocked(store, false))
+
+		results, clusters := evaluate(t, mockContacts, blocked(store, true))
+		fullResults, fullClusters := evaluate(t, mockContacts, blocked(nil, false))
+
+		assert.NotEmpty(t, results)
+		assert.Equal(t, involving(fullResults, added), results)
+		assert.Equal(t, fullClusters, clusters)
+	})
+
+	t.Run("when an indexed contact is no longer in the input, it should remove it from the index", func(t *testing.T) {
+		store := contact.NewIndexStore(pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "index.json"))
+		evaluate(t, indexed, newConfig(store, false))
+
+		results, clusters := evaluate(t, mockContacts[1:], newConfig(store, true))
+
+		assert.Empty(t, involving(results, indexed[:1]))
+		assert.Equal(t, len(mockContacts)-1, len(clusters))
+		assert.False(t, slices.ContainsFunc(clusters, func(a contact.ClusterAssignment) bool { return a.ContactID == indexed[0].ContactID }))
+
+		index, _, err := store.LoadIndex(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, len(mockContacts)-1, len(index.Entries))
+	})
+
+	t.Run("when the model is fellegi-sunter, it should keep scoring with the estimate of the full run", func(t *testing.T) {
+		store := contact.NewIndexStore(pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "index.json"))
+		fellegiSunter := func(store contact.IndexStore, incremental bool) contact.Config {
+			config := newConfig(store, incremental)
+			config.ScoringModel = contact.FellegiSunterModel
+			return config
+		}
+		evaluate(t, indexed, fellegiSunter(store, false))
+		index, _, err := store.LoadIndex(ctx)
+		assert.Nil(t, err)
+		assert.NotNil(t, index.FellegiSunter)
+		assert.Equal(t, 5, len(index.FellegiSunter.Probabilities))
+
+		evaluate(t, mockContacts, fellegiSunter(store, true))
+
+		incremental, _, err := store.LoadIndex(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, index.FellegiSunter, incremental.FellegiSunter)
+		assert.Equal(t, len(mockContacts), len(incremental.Entries))
+	})
+
+	t.Run("when the index has no fellegi-sunter estimate, it should return an error", func(t *testing.T) {
+		store := contact.NewIndexStore(pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "index.json"))
+		evaluate(t, indexed, newConfig(store, false))
+
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		config := newConfig(store, true)
+		config.ScoringModel = contact.FellegiSunterModel
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(ctx))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "the index has no fellegi-sunter estimate")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when an indexed contact changed, it should match it again", func(t *testing.T) {
+		store := contact.NewIndexStore(pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "index.json"))
+		evaluate(t, indexed, newConfig(store, false))
+
+		changed := slices.Clone(indexed)
+		changed[0].Email = "john.doe@example.com"
+		results, _ := evaluate(t, changed, newConfig(store, true))
+
+		assert.NotEmpty(t, results)
+		assert.Equal(t, involving(results, changed[:1]), results)
+	})
+
+	t.Run("when incremental matching has no index, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, newConfig(nil, true)).Evaluate(ctx))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.IndexRequiredError, err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the index was not built yet, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(added, nil)
+		store := new(mocks.IndexStoreMock)
+		store.On("LoadIndex", mock.Anything).Return(contact.MatchIndex{}, false, nil)
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, newConfig(store, true)).Evaluate(ctx))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.IndexNotFoundError, err.Error())
+		store.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the index was built with other block keys, it should return an error", func(t *testing.T) {
+		store := contact.NewIndexStore(pkg.NewJSONConnector(), filepath.Join(t.TempDir(), "index.json"))
+		evaluate(t, indexed, newConfig(store, false))
+
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(added, nil)
+		config := newConfig(store, true)
+		config.BlockKeys = []string{contact.ZipBlockKey}
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(ctx))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.IndexMismatchError)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,41 @@
+package contact
+
+import "errors"
+
+const (
+	LinkageClusteringError = "clustering is not supported when linking two datasets"
+)
+
+// Dataset is the side of a linkage a contact comes from
+type Dataset string
+
+const (
+	SourceDataset    Dataset = "source"
+	ReferenceDataset Dataset = "reference"
+)
+
+// validateLinkage rejects clustering when linking, the contact IDs of both datasets may overlap
+func validateLinkage(config Config) error {
+	if config.Linkage && config.Clustering.Enabled {
+		return errors.New(LinkageClusteringError)
+	}
+	return nil
+}
+
+// assignDatasets marks the records before sources as coming from the source dataset and the rest from the reference one
+func assignDatasets(records []record, sources int) {
+	for i := range records {
+		records[i].dataset = ReferenceDataset
+		if i < sources {
+			records[i].dataset = SourceDataset
+		}
+	}
+}
+
+// key identifies the contact across both datasets of a linkage
+func (r record) key() string {
+	if r.dataset == "" {
+		return r.ContactID
+	}
+	return string(r.dataset) + "/" + r.ContactID
+}
@@ -0,0 +1,92 @@
+package contact_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContactService_EvaluateLinkage(t *testing.T) {
+	logger := logrus.New()
+	// Both datasets number their contacts from 1 and repeat a contact within themselves
+	leads := []contact.Contact{
+		{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+		{ContactID: "2", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+		{ContactID: "3", FirstName: "Mary", LastName: "Major", Email: "mary@example.com", ZipCode: "99999", Address: "9 Elm St"},
+	}
+	crm := []contact.Contact{
+		{ContactID: "1", FirstName: "Jane", LastName: "Smith", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
+		{ContactID: "2", FirstName: "Jane", LastName: "Smith", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
+		{ContactID: "3", FirstName: "Mary", LastName: "Major", Email: "mary@example.com", ZipCode: "99999", Address: "9 Elm Street"},
+	}
+
+	t.Run("when linking two datasets, it should only match contacts across them and tell the side of each ID", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(leads, nil)
+		mockRepo.On("GetReferenceData", mock.Anything).Return(crm, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		results, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{Linkage: true}).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, []contact.ProcessOutput{
+			{ContactIDSource: "3", ContactIDMatch: "3", SourceSide: contact.SourceDataset, MatchSide: contact.ReferenceDataset, AccuracyLevel: 4},
+			{ContactIDSource: "3", ContactIDMatch: "3", SourceSide: contact.ReferenceDataset, MatchSide: contact.SourceDataset, AccuracyLevel: 4},
+		}, results)
+
+		mockRepo.AssertCalled(t, "WriteDuplicateContacts", mock.Anything, []contact.Contact(nil))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when a contact is repeated in the reference dataset, it should be a match of the highest accuracy", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(leads[:1], nil)
+		mockRepo.On("GetReferenceData", mock.Anything).Return(leads[1:2], nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		results, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{Linkage: true}).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, "1", results[0].ContactIDSource)
+		assert.Equal(t, "2", results[0].ContactIDMatch)
+		assert.Equal(t, 5, results[0].AccuracyLevel)
+
+		mockRepo.AssertCalled(t, "WriteDuplicateContacts", mock.Anything, []contact.Contact{leads[0], leads[1]})
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when reading the reference dataset fails, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(leads, nil)
+		mockRepo.On("GetReferenceData", mock.Anything).Return([]contact.Contact(nil), errors.New("failed to read CSV"))
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{Linkage: true}).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to read CSV", err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when clustering is enabled, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		config := contact.Config{
+			Linkage:    true,
+			Clustering: contact.ClusteringConfig{Enabled: true, Method: contact.ConnectedComponentsClustering},
+		}
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.LinkageClusteringError, err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,189 @@
+package contact
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+const (
+	EmptyContactError = "contact has no values to match"
+)
+
+// Candidate is a known contact matching the contact being looked up
+type Candidate struct {
+	Contact       Contact  `json:"contact"`
+	AccuracyLevel int      `json:"accuracy_level"`
+	Accuracy      Accuracy `json:"accuracy"`
+	// Duplicate is true when every field is an exact match, such candidates have the highest accuracy
+	Duplicate     bool   `json:"duplicate,omitempty"`
+	PhoneticMatch string `json:"phonetic_match,omitempty"`
+	// ClusterID is the cluster of the candidate when the contacts come from a match index
+	ClusterID   int                `json:"cluster_id,omitempty"`
+	Explanation []FieldExplanation `json:"explanation,omitempty"`
+}
+
+// matcher holds the known contacts prepared for scoring, so every lookup only scores the contact against them
+type matcher struct {
+	scorer  scorer
+	model   pairScorer
+	blocker blocker
+	records []record
+	// blocks maps the blocks of each block key to the contacts in them
+	blocks   []map[string][]int
+	clusters []int
+}
+
+// lazyMatcher loads the known contacts on the first lookup, a failed load is retried on the next one
+type lazyMatcher struct {
+	mu      sync.Mutex
+	matcher *matcher
+}
+
+// Match scores the contact against the contacts of the repository, or of the match index when one is configured,
+// and returns the ones it matches ranked from the highest accuracy
+func (c contactService) Match(ctx context.Context, contact Contact) ([]Candidate, error) {
+	if contact.FirstName == "" && contact.LastName == "" && contact.Email == "" && contact.ZipCode == "" && contact.Address == "" {
+		return nil, errors.New(EmptyContactError)
+	}
+
+	m, err := c.loadMatcher(ctx)
+	if err != nil {
+		c.log.Errorf("error loading contacts to match: %v", err)
+		return nil, err
+	}
+
+	return m.match(contact, c.config.Explain), nil
+}
+
+func (c contactService) loadMatcher(ctx context.Context) (*matcher, error) {
+	c.matcher.mu.Lock()
+	defer c.matcher.mu.Unlock()
+
+	if c.matcher.matcher != nil {
+		return c.matcher.matcher, nil
+	}
+
+	blocker, err := newBlocker(c.config.BlockKeys, c.config.MaxBlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	accuracyScorer, err := newScorer(c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &matcher{scorer: accuracyScorer, blocker: blocker}
+	var blockKeys [][]string
+	index, found := MatchIndex{}, false
+	if c.config.Index != nil {
+		index, found, err = c.config.Index.LoadIndex(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if found {
+		if err = index.validate(c.config); err != nil {
+			return nil, err
+		}
+
+		for _, entry := range index.Entries {
+			m.records = append(m.records, entry.record())
+			m.clusters = append(m.clusters, entry.ClusterID)
+			blockKeys = append(blockKeys, entry.Blocks)
+		}
+	} else {
+		contacts, err := c.repository.GetContactData(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		m.records = accuracyScorer.prepareAll(contacts)
+		blockKeys = blocker.blockKeys(contacts)
+	}
+
+	m.blocks = make([]map[string][]int, len(blocker.keys))
+	for k := range blocker.keys {
+		m.blocks[k] = blocker.blocks(blockKeys, k)
+	}
+
+	// The Fellegi-Sunter model of the index is reused, otherwise it is estimated once on the pairs of known contacts
+	var pairs []pair
+	if c.config.ScoringModel == FellegiSunterModel && index.FellegiSunter == nil {
+		pairs = blocker.pairsBetween(blockKeys, len(m.records), 0)
+	}
+	m.model, err = c.newPairScorer(ctx, accuracyScorer, m.records, pairs, index.FellegiSunter)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Infof("loaded %d contacts to match", len(m.records))
+	c.matcher.matcher = m
+	return m, nil
+}
+
+// match scores the contact against the known contacts sharing a block with it, or all of them without block keys
+func (m *matcher) match(contact Contact, explain bool) []Candidate {
+	lookup := m.scorer.prepare(contact)
+
+	var known []int
+	if len(m.blocker.keys) == 0 {
+		known = make([]int, len(m.records))
+		for i := range known {
+			known[i] = i
+		}
+	} else {
+		seen := make(map[int]bool)
+		for k, block := range m.blocker.blockKeys([]Contact{contact})[0] {
+			if block == "" {
+				continue
+			}
+			for _, i := range m.blocks[k][block] {
+				if !seen[i] {
+					seen[i] = true
+					known = append(known, i)
+				}
+			}
+		}
+		sort.Ints(known)
+	}
+
+	var candidates []Candidate
+	for _, i := range known {
+		result, ok := compareContacts(m.model, m.records[i], lookup)
+		if !ok {
+			continue
+		}
+
+		// An exact copy of a known contact is the strongest answer to whether the contact already exists
+		if result.duplicate {
+			result.accuracyLevel = 5
+		}
+
+		accuracy, _ := MapLevelToAccuracy(result.accuracyLevel)
+		candidate := Candidate{
+			Contact:       m.records[i].Contact,
+			AccuracyLevel: result.accuracyLevel,
+			Accuracy:      accuracy,
+			Duplicate:     result.duplicate,
+			PhoneticMatch: result.phoneticMatch,
+		}
+		if m.clusters != nil {
+			candidate.ClusterID = m.clusters[i]
+		}
+		if explain {
+			candidate.Explanation = m.model.explain(m.records[i], lookup)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	// Known contacts keep their input order within the same accuracy
+	sort.SliceStable(candidates, func(x, y int) bool {
+		return candidates[x].AccuracyLevel > candidates[y].AccuracyLevel
+	})
+
+	return candidates
+}
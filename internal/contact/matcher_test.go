@@ -0,0 +1,121 @@
+package contact_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContactService_Match(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+	mockContacts := []contact.Contact{
+		{ContactID: "1", FirstName: "Jon", LastName: "Doe", Email: "jon@example.com", ZipCode: "12345", Address: "1 Main St"},
+		{ContactID: "2", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "1 Main St"},
+		{ContactID: "3", FirstName: "Jane", LastName: "Smith", Email: "jane@example.com", ZipCode: "54321", Address: "9 Oak Ave"},
+	}
+	lookup := contact.Contact{FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "1 Main St"}
+
+	t.Run("when the contact matches known contacts, it should rank them from the highest accuracy", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		service := contact.NewContactService(logger, mockRepo, contact.DefaultConfig())
+
+		candidates, err := service.Match(ctx, lookup)
+
+		assert.Nil(t, err)
+		assert.Len(t, candidates, 2)
+		assert.Equal(t, "2", candidates[0].Contact.ContactID)
+		assert.Equal(t, "1", candidates[1].Contact.ContactID)
+		assert.Greater(t, candidates[0].AccuracyLevel, candidates[1].AccuracyLevel)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the contact is an exact copy of a known one, it should be a duplicate of the highest accuracy", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		config := contact.DefaultConfig()
+		config.Explain = true
+		service := contact.NewContactService(logger, mockRepo, config)
+
+		candidates, err := service.Match(ctx, mockContacts[2])
+
+		assert.Nil(t, err)
+		assert.Len(t, candidates, 1)
+		assert.True(t, candidates[0].Duplicate)
+		assert.Equal(t, 5, candidates[0].AccuracyLevel)
+		assert.Equal(t, contact.Accuracy("Very High"), candidates[0].Accuracy)
+		assert.NotEmpty(t, candidates[0].Explanation)
+	})
+
+	t.Run("when matching several contacts, it should load the known contacts once", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil).Once()
+		service := contact.NewContactService(logger, mockRepo, contact.DefaultConfig())
+
+		_, err := service.Match(ctx, lookup)
+		assert.Nil(t, err)
+		_, err = service.Match(ctx, mockContacts[0])
+		assert.Nil(t, err)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the contact has no values, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		service := contact.NewContactService(logger, mockRepo, contact.DefaultConfig())
+
+		_, err := service.Match(ctx, contact.Contact{ContactID: "4"})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.EmptyContactError, err.Error())
+		mockRepo.AssertNotCalled(t, "GetContactData", mock.Anything)
+	})
+
+	t.Run("when a match index exists, it should match against the indexed contacts with their clusters", func(t *testing.T) {
+		var index contact.MatchIndex
+		store := new(mocks.IndexStoreMock)
+		store.On("SaveIndex", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			index = args.Get(1).(contact.MatchIndex)
+		}).Return(nil)
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil).Once()
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteClusters", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteMergedContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteContactMapping", mock.Anything, mock.Anything).Return(nil)
+		config := contact.DefaultConfig()
+		config.Index = store
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(ctx))
+		assert.Nil(t, err)
+
+		lookupStore := new(mocks.IndexStoreMock)
+		lookupStore.On("LoadIndex", mock.Anything).Return(index, true, nil)
+		config.Index = lookupStore
+		candidates, err := contact.NewContactService(logger, new(mocks.RepositoryMock), config).Match(ctx, lookup)
+
+		assert.Nil(t, err)
+		assert.Len(t, candidates, 2)
+		assert.Equal(t, candidates[0].ClusterID, candidates[1].ClusterID)
+		assert.NotZero(t, candidates[0].ClusterID)
+		lookupStore.AssertExpectations(t)
+	})
+
+	t.Run("when the known contacts cannot be read, it should return the error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return([]contact.Contact(nil), errors.New("read error"))
+		service := contact.NewContactService(logger, mockRepo, contact.DefaultConfig())
+
+		_, err := service.Match(ctx, lookup)
+
+		assert.NotNil(t, err)
+	})
+}
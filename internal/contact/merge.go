@@ -0,0 +1,226 @@
+package contact
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	UnknownSurvivorshipRuleError = "unknown survivorship rule"
+	MergeWithoutClusteringError  = "merging requires clustering to be enabled"
+	UnknownMergeFieldError       = "unknown merge field"
+)
+
+type SurvivorshipRule string
+
+const (
+	MostFrequentRule    SurvivorshipRule = "most_frequent"
+	LongestRule         SurvivorshipRule = "longest"
+	NonEmptyRule        SurvivorshipRule = "non_empty"
+	PreferredSourceRule SurvivorshipRule = "preferred_source"
+	MostRecentRule      SurvivorshipRule = "most_recent"
+)
+
+// updatedAtFormats are the accepted formats of Contact.UpdatedAt
+var updatedAtFormats = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// MergeConfig builds a golden record per cluster choosing each field value with its survivorship rule
+type MergeConfig struct {
+	Enabled bool `json:"enabled"`
+	// Rules per field, fields without a rule keep the first non-empty value
+	Rules map[Field]SurvivorshipRule `json:"rules"`
+	// PreferredSources lists the contact sources from most to least trusted, used by the preferred_source rule
+	PreferredSources []string `json:"preferred_sources"`
+}
+
+func (m MergeConfig) validate(clustering ClusteringConfig) error {
+	if !m.Enabled {
+		return nil
+	}
+
+	if !clustering.Enabled {
+		return errors.New(MergeWithoutClusteringError)
+	}
+
+	for field, rule := range m.Rules {
+		if !slices.Contains(comparedFields[:], field) {
+			return fmt.Errorf("%s: %q", UnknownMergeFieldError, field)
+		}
+
+		switch rule {
+		case MostFrequentRule, LongestRule, NonEmptyRule, PreferredSourceRule, MostRecentRule:
+		default:
+			return fmt.Errorf("field %s: %s: %q", field, UnknownSurvivorshipRuleError, rule)
+		}
+	}
+
+	return nil
+}
+
+// mergeClusters builds one golden contact per cluster and maps every contact to the ID of its golden contact.
+// The surviving ID is the one of the first contact of the cluster in input order, and the placeholder and invalid
+// values of the validator only survive when no member has a valid one.
+func mergeClusters(contacts []Contact, assignments []ClusterAssignment, config MergeConfig, valid validator) ([]Contact, []ContactMapping) {
+	var clusterOrder []int
+	members := make(map[int][]Contact)
+	for i, assignment := range assignments {
+		if _, ok := members[assignment.ClusterID]; !ok {
+			clusterOrder = append(clusterOrder, assignment.ClusterID)
+		}
+		members[assignment.ClusterID] = append(members[assignment.ClusterID], contacts[i])
+	}
+
+	merged := make([]Contact, 0, len(clusterOrder))
+	for _, clusterID := range clusterOrder {
+		merged = append(merged, config.goldenRecord(members[clusterID], valid))
+	}
+
+	mapping := make([]ContactMapping, len(contacts))
+	for i, assignment := range assignments {
+		mapping[i] = ContactMapping{
+			ContactID:          contacts[i].ContactID,
+			SurvivingContactID: members[assignment.ClusterID][0].ContactID,
+		}
+	}
+
+	return merged, mapping
+}
+
+func (m MergeConfig) goldenRecord(members []Contact, valid validator) Contact {
+	golden := Contact{
+		ContactID: members[0].ContactID,
+		Source:    members[0].Source,
+		UpdatedAt: members[0].UpdatedAt,
+	}
+
+	if latest, ok := mostRecent(members); ok {
+		golden.UpdatedAt = latest.UpdatedAt
+	}
+
+	golden.FirstName = m.survive(FirstNameField, members, valid, func(c Contact) string { return c.FirstName })
+	golden.LastName = m.survive(LastNameField, members, valid, func(c Contact) string { return c.LastName })
+	golden.Email = m.survive(EmailField, members, valid, func(c Contact) string { return c.Email })
+	golden.ZipCode = m.survive(ZipCodeField, members, valid, func(c Contact) string { return c.ZipCode })
+	golden.Address = m.survive(AddressField, members, valid, func(c Contact) string { return c.Address })
+
+	return golden
+}
+
+// survive applies the field rule to the members with a valid value, falling back to the first non-empty value when
+// the rule can't decide
+func (m MergeConfig) survive(field Field, members []Contact, valid validator, value func(Contact) string) string {
+	candidates := slices.DeleteFunc(slices.Clone(members), func(member Contact) bool {
+		validity := valid.check(field, value(member))
+		return validity == PlaceholderValue || validity == InvalidValue
+	})
+	if len(candidates) == 0 {
+		return firstNonEmptyValue(members, value)
+	}
+	members = candidates
+
+	var survivor string
+
+	switch m.Rules[field] {
+	case MostFrequentRule:
+		survivor = mostFrequentValue(members, value)
+	case LongestRule:
+		survivor = longestValue(members, value)
+	case PreferredSourceRule:
+		survivor = m.preferredSourceValue(members, value)
+	case MostRecentRule:
+		survivor = mostRecentValue(members, value)
+	}
+
+	if survivor == "" {
+		survivor = firstNonEmptyValue(members, value)
+	}
+
+	return survivor
+}
+
+func firstNonEmptyValue(members []Contact, value func(Contact) string) string {
+	for _, member := range members {
+		if v := value(member); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// mostFrequentValue breaks ties with the value that appears first
+func mostFrequentValue(members []Contact, value func(Contact) string) string {
+	counts := make(map[string]int)
+	var survivor string
+	for _, member := range members {
+		v := value(member)
+		if v == "" {
+			continue
+		}
+		counts[v]++
+		if counts[v] > counts[survivor] {
+			survivor = v
+		}
+	}
+	return survivor
+}
+
+func longestValue(members []Contact, value func(Contact) string) string {
+	var survivor string
+	for _, member := range members {
+		if v := value(member); len(v) > len(survivor) {
+			survivor = v
+		}
+	}
+	return survivor
+}
+
+func (m MergeConfig) preferredSourceValue(members []Contact, value func(Contact) string) string {
+	for _, source := range m.PreferredSources {
+		for _, member := range members {
+			if v := value(member); member.Source == source && v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func mostRecentValue(members []Contact, value func(Contact) string) string {
+	var survivor string
+	var latest time.Time
+	for _, member := range members {
+		v := value(member)
+		updatedAt, ok := parseUpdatedAt(member.UpdatedAt)
+		if v == "" || !ok {
+			continue
+		}
+		if survivor == "" || updatedAt.After(latest) {
+			survivor, latest = v, updatedAt
+		}
+	}
+	return survivor
+}
+
+func mostRecent(members []Contact) (Contact, bool) {
+	var latest Contact
+	var latestTime time.Time
+	var found bool
+	for _, member := range members {
+		updatedAt, ok := parseUpdatedAt(member.UpdatedAt)
+		if ok && (!found || updatedAt.After(latestTime)) {
+			latest, latestTime, found = member, updatedAt, true
+		}
+	}
+	return latest, found
+}
+
+func parseUpdatedAt(value string) (time.Time, bool) {
+	for _, format := range updatedAtFormats {
+		if parsed, err := time.Parse(format, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
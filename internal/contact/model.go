@@ -1,9 +1,13 @@
 package contact
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 const (
-	InvalidLevelError = "invalid level"
+	InvalidLevelError   = "invalid level"
+	InvalidCutoffsError = "invalid accuracy cutoffs"
 )
 
 type Accuracy string
@@ -23,12 +27,30 @@ type Contact struct {
 	Email     string `json:"email"`
 	ZipCode   string `json:"zip_code"`
 	Address   string `json:"address"`
+	Source    string `json:"source,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
 }
 
 type ProcessOutput struct {
 	ContactIDSource string `json:"contact_id_source"`
 	ContactIDMatch  string `json:"contact_id_match"`
-	AccuracyLevel   int    `json:"accuracy"`
+	// SourceSide and MatchSide are the datasets of both contacts when linking, empty when deduplicating
+	SourceSide    Dataset `json:"source_side,omitempty"`
+	MatchSide     Dataset `json:"match_side,omitempty"`
+	AccuracyLevel int     `json:"accuracy"`
+	PhoneticMatch string  `json:"phonetic_match,omitempty"`
+	// Explanation breaks the accuracy down by field, it is only filled when explanations are enabled
+	Explanation []FieldExplanation `json:"explanation,omitempty"`
+}
+
+type ClusterAssignment struct {
+	ContactID string `json:"contact_id"`
+	ClusterID int    `json:"cluster_id"`
+}
+
+type ContactMapping struct {
+	ContactID          string `json:"contact_id"`
+	SurvivingContactID string `json:"surviving_contact_id"`
 }
 
 func MapLevelToAccuracy(level int) (Accuracy, error) {
@@ -46,3 +68,35 @@ func MapLevelToAccuracy(level int) (Accuracy, error) {
 
 	return accuracyMap[level], nil
 }
+
+// AccuracyCutoffs are the scores from which a pair reaches each accuracy level, from Very Low to Very High
+type AccuracyCutoffs []float64
+
+func (a AccuracyCutoffs) validate() error {
+	if len(a) == 0 {
+		return nil
+	}
+
+	if len(a) != 5 {
+		return fmt.Errorf("%s: %v, it must have a cutoff per accuracy level", InvalidCutoffsError, []float64(a))
+	}
+
+	for i, cutoff := range a {
+		if cutoff <= 0 || (i > 0 && cutoff <= a[i-1]) {
+			return fmt.Errorf("%s: %v, they must be greater than 0 and ascending", InvalidCutoffsError, []float64(a))
+		}
+	}
+
+	return nil
+}
+
+// level returns the highest accuracy level whose cutoff the score reaches, 0 when it isn't a match
+func (a AccuracyCutoffs) level(score float64) int {
+	level := 0
+	for i, cutoff := range a {
+		if score >= cutoff {
+			level = i + 1
+		}
+	}
+	return level
+}
@@ -0,0 +1,106 @@
+package contact
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	InvalidNicknameRecordError = "invalid nickname record"
+)
+
+//go:embed data/nicknames.csv
+var embeddedNicknames string
+
+// NicknameTable knows which given names each name may stand for, so "Bob" and "Robert" are the same first name
+type NicknameTable struct {
+	givenNames map[string]map[string]bool
+}
+
+// NewNicknameTable returns a table loaded with the embedded nickname dictionary
+func NewNicknameTable() *NicknameTable {
+	table := &NicknameTable{givenNames: make(map[string]map[string]bool)}
+
+	records, err := csv.NewReader(strings.NewReader(embeddedNicknames)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("error reading embedded nicknames: %v", err))
+	}
+
+	if err = table.Load(records); err != nil {
+		panic(fmt.Sprintf("error loading embedded nicknames: %v", err))
+	}
+
+	return table
+}
+
+// LoadNicknameTable returns the embedded table extended with the aliases of the given CSV file
+func LoadNicknameTable(ctx context.Context, csvConnector pkg.CSVConnector, filePath string) (*NicknameTable, error) {
+	table := NewNicknameTable()
+
+	records, err := csvConnector.ReadCSV(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = table.Load(records); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// Load adds "name,nickname" records to the table, the first record is the header
+func (t *NicknameTable) Load(records [][]string) error {
+	if len(records) < 1 {
+		return nil
+	}
+
+	for i, record := range records[1:] {
+		if len(record) != 2 || strings.TrimSpace(record[0]) == "" || strings.TrimSpace(record[1]) == "" {
+			return fmt.Errorf("%s %d: expected a name and a nickname", InvalidNicknameRecordError, i+1)
+		}
+
+		t.Add(record[0], record[1])
+	}
+
+	return nil
+}
+
+// Add registers the nickname as a short form of the given name
+func (t *NicknameTable) Add(name, nickname string) {
+	name = normalizeName(name)
+	t.addGivenName(name, name)
+	t.addGivenName(normalizeName(nickname), name)
+}
+
+func (t *NicknameTable) addGivenName(name, givenName string) {
+	if t.givenNames[name] == nil {
+		t.givenNames[name] = make(map[string]bool)
+	}
+	t.givenNames[name][givenName] = true
+}
+
+// Equivalent reports whether both names may stand for the same given name, e.g. "Bill" and "William" or "Bill" and "Will"
+func (t *NicknameTable) Equivalent(name1, name2 string) bool {
+	if t == nil {
+		return false
+	}
+
+	givenNames1, givenNames2 := t.givenNames[normalizeName(name1)], t.givenNames[normalizeName(name2)]
+	for givenName := range givenNames1 {
+		if givenNames2[givenName] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,81 @@
+package contact_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNicknameTable_Equivalent(t *testing.T) {
+	table := contact.NewNicknameTable()
+
+	t.Run("when a name is a nickname of the other, it should be equivalent", func(t *testing.T) {
+		assert.True(t, table.Equivalent("Bob", "Robert"))
+		assert.True(t, table.Equivalent("william", "BILL"))
+		assert.True(t, table.Equivalent("Peggy", "Margaret"))
+	})
+
+	t.Run("when both names are nicknames of the same given name, it should be equivalent", func(t *testing.T) {
+		assert.True(t, table.Equivalent("Bill", "Will"))
+	})
+
+	t.Run("when names only share a nickname, it should not be equivalent", func(t *testing.T) {
+		assert.False(t, table.Equivalent("Albert", "Alfred"))
+		assert.False(t, table.Equivalent("Robert", "William"))
+	})
+
+	t.Run("when the table is nil, it should not be equivalent", func(t *testing.T) {
+		var nilTable *contact.NicknameTable
+		assert.False(t, nilTable.Equivalent("Bob", "Robert"))
+	})
+}
+
+func TestLoadNicknameTable(t *testing.T) {
+	t.Run("when loading company aliases, it should extend the embedded table", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		mockCsv.On("ReadCSV", mock.Anything, "aliases.csv").Return([][]string{
+			{"name", "nickname"},
+			{"Guillermo", "Memo"},
+		}, nil)
+
+		table, err := contact.LoadNicknameTable(context.Background(), mockCsv, "aliases.csv")
+
+		assert.Nil(t, err)
+		assert.True(t, table.Equivalent("Memo", "Guillermo"))
+		assert.True(t, table.Equivalent("Bob", "Robert"))
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when an alias record is malformed, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		mockCsv.On("ReadCSV", mock.Anything, "aliases.csv").Return([][]string{
+			{"name", "nickname"},
+			{"Guillermo", ""},
+		}, nil)
+
+		_, err := contact.LoadNicknameTable(context.Background(), mockCsv, "aliases.csv")
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidNicknameRecordError)
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when the aliases file cannot be read, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		mockCsv.On("ReadCSV", mock.Anything, "aliases.csv").Return([][]string{}, errors.New("failed to read CSV"))
+
+		_, err := contact.LoadNicknameTable(context.Background(), mockCsv, "aliases.csv")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to read CSV", err.Error())
+
+		mockCsv.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,70 @@
+package contact
+
+import (
+	"fmt"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	UnknownPhoneticEncoderError = "unknown phonetic encoder"
+)
+
+type PhoneticEncoder string
+
+const (
+	SoundexEncoder         PhoneticEncoder = "soundex"
+	MetaphoneEncoder       PhoneticEncoder = "metaphone"
+	DoubleMetaphoneEncoder PhoneticEncoder = "double_metaphone"
+)
+
+// phoneticKeys holds the keys of a name for each encoder
+type phoneticKeys map[PhoneticEncoder][]string
+
+// phoneticEncoders return the keys of a name, two names sound alike when they share a key
+var phoneticEncoders = map[PhoneticEncoder]func(name string) []string{
+	SoundexEncoder: func(name string) []string {
+		return []string{pkg.Soundex(name)}
+	},
+	MetaphoneEncoder: func(name string) []string {
+		return []string{pkg.Metaphone(name)}
+	},
+	DoubleMetaphoneEncoder: func(name string) []string {
+		primary, alternate := pkg.DoubleMetaphone(name)
+		return []string{primary, alternate}
+	},
+}
+
+func validatePhoneticEncoders(encoders []PhoneticEncoder) error {
+	for _, encoder := range encoders {
+		if _, ok := phoneticEncoders[encoder]; !ok {
+			return fmt.Errorf("%s: %q", UnknownPhoneticEncoderError, encoder)
+		}
+	}
+	return nil
+}
+
+func encodePhonetic(encoders []PhoneticEncoder, name string) phoneticKeys {
+	keys := make(phoneticKeys, len(encoders))
+	for _, encoder := range encoders {
+		keys[encoder] = phoneticEncoders[encoder](name)
+	}
+	return keys
+}
+
+// matchPhonetic returns the first encoder, in the configured order, that gives both names a common key
+func matchPhonetic(encoders []PhoneticEncoder, keys1, keys2 phoneticKeys) (PhoneticEncoder, bool) {
+	for _, encoder := range encoders {
+		for _, key1 := range keys1[encoder] {
+			if key1 == "" {
+				continue
+			}
+			for _, key2 := range keys2[encoder] {
+				if key1 == key2 {
+					return encoder, true
+				}
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,306 @@
+package contact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	TransactionInProgressError = "a transaction is already in progress"
+)
+
+const (
+	defaultFetchSize = 1000
+	defaultBatchSize = 10000
+	// contactsCursor is the cursor the contacts are fetched from, it only lives in the read transaction
+	contactsCursor = "contacts_cursor"
+)
+
+// PostgresDB is satisfied by *pgx.Conn and *pgxpool.Pool
+type PostgresDB interface {
+	BeginTx(ctx context.Context, options pgx.TxOptions) (pgx.Tx, error)
+}
+
+// PostgresConfig tells where the Postgres repository reads the contacts from and how many rows each round trip moves
+type PostgresConfig struct {
+	DatabaseConfig
+	// FetchSize is the number of contacts fetched from the cursor at a time, 0 uses 1000
+	FetchSize int
+	// BatchSize is the number of rows sent by each COPY, 0 uses 10000
+	BatchSize int
+}
+
+type postgresRepository struct {
+	log     *logrus.Logger
+	db      PostgresDB
+	config  PostgresConfig
+	columns ColumnMapping
+	// tx is the transaction of the run between Begin and Commit or Rollback, every output is written in it
+	tx pgx.Tx
+}
+
+// NewPostgresRepository streams the contacts of the configured table or query through a cursor, its columns are found
+// by name with the given mapping like a CSV header, a nil mapping uses DefaultColumnMapping. Every output is copied to
+// its own table, in the transaction of the run when one was begun, see Transactional.
+func NewPostgresRepository(log *logrus.Logger, db PostgresDB, config PostgresConfig, columns ColumnMapping) Repository {
+	if columns == nil {
+		columns = DefaultColumnMapping()
+	}
+
+	return &postgresRepository{
+		log:     log,
+		db:      db,
+		config:  config,
+		columns: columns,
+	}
+}
+
+func (p *postgresRepository) GetContactData(ctx context.Context) ([]Contact, error) {
+	return p.readContacts(ctx, p.config.ContactsTable, p.config.ContactsQuery)
+}
+
+func (p *postgresRepository) GetReferenceData(ctx context.Context) ([]Contact, error) {
+	return p.readContacts(ctx, p.config.ReferenceTable, p.config.ReferenceQuery)
+}
+
+func (p *postgresRepository) readContacts(ctx context.Context, table, query string) ([]Contact, error) {
+	contacts, err := p.fetchContacts(ctx, table, query)
+	if err != nil {
+		p.log.Errorf("Error reading contacts: %v", err)
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// fetchContacts declares a cursor for the query in a read only transaction, so the contacts are fetched in batches
+func (p *postgresRepository) fetchContacts(ctx context.Context, table, query string) ([]Contact, error) {
+	query, err := sourceQuery(table, query)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := p.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	// Nothing was written, ending the transaction closes the cursor
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DECLARE "+contactsCursor+" NO SCROLL CURSOR FOR "+query); err != nil {
+		return nil, fmt.Errorf("error querying contacts: %w", err)
+	}
+
+	return parseContacts(fetchRows(ctx, tx, positiveOr(p.config.FetchSize, defaultFetchSize)), p.columns, skipNoRows)
+}
+
+// fetchRows yields the column names of the cursor and then the values of every row as text, NULL is empty.
+// The simple protocol returns every value as text whatever its type.
+func fetchRows(ctx context.Context, tx pgx.Tx, fetchSize int) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", fetchSize, contactsCursor)
+		var header []string
+
+		for {
+			rows, err := tx.Query(ctx, fetch, pgx.QueryExecModeSimpleProtocol)
+			if err != nil {
+				yield(nil, fmt.Errorf("error fetching contacts: %w", err))
+				return
+			}
+
+			if header == nil {
+				for _, field := range rows.FieldDescriptions() {
+					header = append(header, field.Name)
+				}
+				if !yield(header, nil) {
+					rows.Close()
+					return
+				}
+			}
+
+			fetched := 0
+			for rows.Next() {
+				fetched++
+				values := rows.RawValues()
+				record := make([]string, len(values))
+				for i, value := range values {
+					record[i] = string(value)
+				}
+
+				if !yield(record, nil) {
+					rows.Close()
+					return
+				}
+			}
+
+			rows.Close()
+			if err = rows.Err(); err != nil {
+				yield(nil, fmt.Errorf("error fetching contacts: %w", err))
+				return
+			}
+
+			// A short batch is the last one
+			if fetched < fetchSize {
+				return
+			}
+		}
+	}
+}
+
+// Begin starts the transaction every output of the run is written in
+func (p *postgresRepository) Begin(ctx context.Context) error {
+	if p.tx != nil {
+		return errors.New(TransactionInProgressError)
+	}
+
+	tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		p.log.Errorf("Error beginning transaction: %v", err)
+		return err
+	}
+
+	p.tx = tx
+	return nil
+}
+
+// Commit makes every output of the run visible at once
+func (p *postgresRepository) Commit(ctx context.Context) error {
+	if p.tx == nil {
+		return nil
+	}
+
+	err := p.tx.Commit(ctx)
+	p.tx = nil
+	if err != nil {
+		p.log.Errorf("Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Rollback discards every output of the run, the tables keep the outputs of the previous run
+func (p *postgresRepository) Rollback(ctx context.Context) error {
+	if p.tx == nil {
+		return nil
+	}
+
+	err := p.tx.Rollback(ctx)
+	p.tx = nil
+	if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		p.log.Errorf("Error rolling back transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// inTransaction writes in the transaction of the run, or in a transaction of its own when no run began one
+func (p *postgresRepository) inTransaction(ctx context.Context, write func(tx pgx.Tx) error) error {
+	if p.tx != nil {
+		return write(p.tx)
+	}
+
+	tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err = write(tx); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// copyRows replaces the table with a row per value, copied in batches of the configured size
+func copyRows[T any](ctx context.Context, p *postgresRepository, table outputTable[T], values iter.Seq[T]) error {
+	batchSize := positiveOr(p.config.BatchSize, defaultBatchSize)
+	table = table.prefixed(p.config.OutputPrefix)
+
+	err := p.inTransaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, table.drop()); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, table.create()); err != nil {
+			return err
+		}
+
+		batch := make([][]any, 0, batchSize)
+		copyBatch := func() error {
+			_, err := tx.CopyFrom(ctx, pgx.Identifier{table.name}, table.columnNames(), pgx.CopyFromRows(batch))
+			batch = batch[:0]
+			return err
+		}
+
+		for value := range values {
+			if batch = append(batch, table.row(value)); len(batch) == batchSize {
+				if err := copyBatch(); err != nil {
+					return err
+				}
+			}
+		}
+		if len(batch) > 0 {
+			if err := copyBatch(); err != nil {
+				return err
+			}
+		}
+
+		// Indexing once every row is copied is faster than updating the indices on each batch
+		for _, index := range table.createIndexes() {
+			if _, err := tx.Exec(ctx, index); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error writing to table %s: %v", table.name, err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *postgresRepository) WriteContactData(ctx context.Context, data iter.Seq[ProcessOutput]) error {
+	return copyRows(ctx, p, matchesTable, data)
+}
+
+func (p *postgresRepository) WriteDuplicateContacts(ctx context.Context, data iter.Seq[Contact]) error {
+	return copyRows(ctx, p, duplicatesTable, data)
+}
+
+func (p *postgresRepository) WriteClusters(ctx context.Context, assignments iter.Seq[ClusterAssignment]) error {
+	return copyRows(ctx, p, clustersTable, assignments)
+}
+
+func (p *postgresRepository) WriteMergedContacts(ctx context.Context, contacts iter.Seq[Contact]) error {
+	return copyRows(ctx, p, mergedTable, contacts)
+}
+
+func (p *postgresRepository) WriteContactMapping(ctx context.Context, mapping iter.Seq[ContactMapping]) error {
+	return copyRows(ctx, p, mappingTable, mapping)
+}
+
+// WriteExplanations writes a row per field of every match
+func (p *postgresRepository) WriteExplanations(ctx context.Context, data iter.Seq[ProcessOutput]) error {
+	return copyRows(ctx, p, explanationsTable, explanationRows(data))
+}
+
+func (p *postgresRepository) WriteQualityReport(ctx context.Context, report []FieldQuality) error {
+	return copyRows(ctx, p, qualityTable, slices.Values(report))
+}
+
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
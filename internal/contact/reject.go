@@ -0,0 +1,66 @@
+package contact
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	TooManyRejectsError = "too many rejected rows"
+)
+
+// Reject is an input row skipped because it could not be read as a contact
+type Reject struct {
+	// Dataset is the dataset the row was read from when linking, empty when deduplicating
+	Dataset Dataset `json:"dataset,omitempty"`
+	// Line is the line of the CSV file the row starts on, the header is line 1, or the position of the JSON object
+	Line   int      `json:"line"`
+	Reason string   `json:"reason"`
+	Record []string `json:"record"`
+}
+
+// Rejecting is implemented by the repositories skipping the input rows they can't read, instead of failing
+type Rejecting interface {
+	// Rejects lists the rows skipped by the last reading of every dataset
+	Rejects() []Reject
+	WriteRejects(ctx context.Context, rejects []Reject) error
+}
+
+// fieldCountReason tells why a row with a different number of fields than the header was rejected
+func fieldCountReason(fields, headerFields int) string {
+	return fmt.Sprintf("record has %d fields, the header has %d", fields, headerFields)
+}
+
+// checkRejects lists the rejected rows of a Rejecting repository and fails when they exceed the configured maximum,
+// before any pair is scored
+func (c contactService) checkRejects() ([]Reject, error) {
+	rejecting, ok := c.repository.(Rejecting)
+	if !ok {
+		return nil, nil
+	}
+
+	rejects := rejecting.Rejects()
+	if c.config.MaxRejects > 0 && len(rejects) > c.config.MaxRejects {
+		err := fmt.Errorf("%s: %d rows rejected, at most %d allowed", TooManyRejectsError, len(rejects), c.config.MaxRejects)
+		c.log.Errorf("error reading contacts: %v", err)
+		return nil, err
+	}
+
+	return rejects, nil
+}
+
+// writeRejects writes the rejected rows of a Rejecting repository along with the other outputs
+func (c contactService) writeRejects(ctx context.Context, rejects []Reject) error {
+	rejecting, ok := c.repository.(Rejecting)
+	if !ok {
+		return nil
+	}
+
+	err := rejecting.WriteRejects(ctx, rejects)
+	if err != nil {
+		c.log.Errorf("error writing rejected rows: %v", err)
+		return err
+	}
+
+	return nil
+}
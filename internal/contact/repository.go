@@ -1,33 +1,119 @@
 package contact
 
 import (
+	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"github.com/sebastianreh/compass-code-assessment/pkg"
 	"github.com/sirupsen/logrus"
+	"iter"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 )
 
+const (
+	NoRecordsError = "no records found"
+)
+
+// Paths holds the location of the input file and of every output file
+type Paths struct {
+	Input string
+	// Reference is the dataset the input is linked to, empty when deduplicating the input
+	Reference    string
+	Output       string
+	Duplicates   string
+	Clusters     string
+	Merged       string
+	Mapping      string
+	Explanations string
+	// Quality receives the data quality report of the input when validating
+	Quality string
+	// Rejects receives the input rows that could not be read, empty disables the file
+	Rejects string
+}
+
+// DefaultPaths reads and writes every file inside the files folder
+func DefaultPaths() Paths {
+	return OutputPaths(filepath.Join("files", "input.csv"), "files")
+}
+
+// OutputPaths places every output file inside the output directory with its default name
+func OutputPaths(input, outputDir string) Paths {
+	return FormatOutputPaths(input, outputDir, pkg.CSVFormat)
+}
+
+// FormatOutputPaths places every output file inside the output directory with its default name and the extension of format
+func FormatOutputPaths(input, outputDir string, format pkg.Format) Paths {
+	extension := format.Extension()
+	return Paths{
+		Input:        input,
+		Output:       filepath.Join(outputDir, "output"+extension),
+		Duplicates:   filepath.Join(outputDir, "duplicate"+extension),
+		Clusters:     filepath.Join(outputDir, "clusters"+extension),
+		Merged:       filepath.Join(outputDir, "merged"+extension),
+		Mapping:      filepath.Join(outputDir, "mapping"+extension),
+		Explanations: filepath.Join(outputDir, "explanations"+extension),
+		Quality:      filepath.Join(outputDir, "data_quality"+extension),
+		Rejects:      filepath.Join(outputDir, "rejects"+extension),
+	}
+}
+
+// Outputs lists the path of every output file
+func (p Paths) Outputs() []string {
+	return []string{p.Output, p.Duplicates, p.Clusters, p.Merged, p.Mapping, p.Explanations, p.Quality, p.Rejects}
+}
+
 type contactRepository struct {
-	log *logrus.Logger
-	csv pkg.CSVConnector
+	log     *logrus.Logger
+	records pkg.RecordConnector
+	paths   Paths
+	columns ColumnMapping
+	// rejects holds the rows skipped by the last reading of each dataset
+	rejects *[]Reject
 }
 
-func NewContactRepository(log *logrus.Logger, csv pkg.CSVConnector) Repository {
+// NewContactRepository reads the input columns with the given mapping, a nil mapping uses DefaultColumnMapping.
+// Every file is read and written in the format of its extension, CSV files go through csv.
+func NewContactRepository(log *logrus.Logger, csv pkg.CSVConnector, paths Paths, columns ColumnMapping) Repository {
+	if columns == nil {
+		columns = DefaultColumnMapping()
+	}
+
 	return &contactRepository{
-		log: log,
-		csv: csv,
+		log:     log,
+		records: pkg.NewRecordConnector(csv),
+		paths:   paths,
+		columns: columns,
+		rejects: &[]Reject{},
 	}
 }
 
-func (c contactRepository) GetContactData() ([]Contact, error) {
-	filePath := filepath.Join("files", "input.csv")
-	records, err := c.csv.ReadCSV(filePath)
-	if err != nil {
-		c.log.Errorf("Error reading CSV file: %v", err)
-		return nil, err
+func (c contactRepository) GetContactData(ctx context.Context) ([]Contact, error) {
+	var dataset Dataset
+	if c.linking() {
+		dataset = SourceDataset
 	}
+	return c.readContacts(ctx, c.paths.Input, dataset)
+}
+
+func (c contactRepository) GetReferenceData(ctx context.Context) ([]Contact, error) {
+	return c.readContacts(ctx, c.paths.Reference, ReferenceDataset)
+}
 
-	contacts, err := c.parseRecords(records)
+// readContacts skips the rows that can't be read, such as a row with a different number of fields than the header,
+// replacing the rejects of the dataset
+func (c contactRepository) readContacts(ctx context.Context, filePath string, dataset Dataset) ([]Contact, error) {
+	*c.rejects = slices.DeleteFunc(*c.rejects, func(reject Reject) bool { return reject.Dataset == dataset })
+
+	contacts, err := parseContacts(c.records.StreamRecords(ctx, filePath), c.columns, func(reject Reject) error {
+		c.log.Errorf("record on line %d rejected: %s", reject.Line, reject.Reason)
+		reject.Dataset = dataset
+		*c.rejects = append(*c.rejects, reject)
+		return nil
+	})
 	if err != nil {
 		c.log.Errorf("Error parsing records: %v", err)
 		return nil, err
@@ -36,95 +122,314 @@ func (c contactRepository) GetContactData() ([]Contact, error) {
 	return contacts, nil
 }
 
-func (c contactRepository) parseRecords(records [][]string) ([]Contact, error) {
-	if len(records) < 1 {
-		return nil, fmt.Errorf("no records found in CSV file")
+// ParseContacts reads the contacts of CSV rows whose first row is the header, a row that can't be read fails
+func ParseContacts(rows iter.Seq2[[]string, error], columns ColumnMapping) ([]Contact, error) {
+	if columns == nil {
+		columns = DefaultColumnMapping()
 	}
 
+	return parseContacts(rows, columns, func(reject Reject) error {
+		return fmt.Errorf("record on line %d: %s", reject.Line, reject.Reason)
+	})
+}
+
+// parseContacts resolves the header and parses every row, invalidRecord decides whether a row yielded as a
+// *pkg.RecordError is skipped or stops the parsing
+func parseContacts(rows iter.Seq2[[]string, error], columnMapping ColumnMapping, invalidRecord func(reject Reject) error) ([]Contact, error) {
 	var contacts []Contact
-	header := records[0]
+	var columns map[Field]int
+	var header []string
 
-	for i, record := range records[1:] {
-		if len(record) != len(header) {
-			c.log.Errorf("record %d has a different number of fields than header", i+1)
+	for record, err := range rows {
+		var recordErr *pkg.RecordError
+		if header != nil && errors.As(err, &recordErr) {
+			reason := recordErr.Err.Error()
+			if recordErr.Record != nil && len(recordErr.Record) != len(header) {
+				reason = fieldCountReason(len(recordErr.Record), len(header))
+			}
+
+			if err = invalidRecord(Reject{Line: recordErr.Line, Reason: reason, Record: recordErr.Record}); err != nil {
+				return nil, err
+			}
 			continue
 		}
+		if err != nil {
+			return nil, err
+		}
 
-		contact := Contact{
-			ContactID: record[0],
-			FirstName: record[1],
-			LastName:  record[2],
-			Email:     record[3],
-			ZipCode:   record[4],
-			Address:   record[5],
+		if header == nil {
+			header = record
+			columns, err = columnMapping.resolve(header)
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
 
-		contacts = append(contacts, contact)
+		// Every source yields the records with a different number of fields as errors
+		if len(record) != len(header) {
+			return nil, errors.New(fieldCountReason(len(record), len(header)))
+		}
+
+		contacts = append(contacts, parseRecord(record, columns))
+	}
+
+	if header == nil {
+		return nil, errors.New(NoRecordsError)
 	}
 
 	return contacts, nil
 }
 
-func (c contactRepository) WriteContactData(data []ProcessOutput) error {
-	filePath := filepath.Join("files", "output.csv")
-	header, csvData := c.convertProcessOutputToCSV(data)
+func parseRecord(record []string, columns map[Field]int) Contact {
+	contact := Contact{
+		ContactID: record[columns[ContactIDField]],
+		FirstName: record[columns[FirstNameField]],
+		LastName:  record[columns[LastNameField]],
+		Email:     record[columns[EmailField]],
+		ZipCode:   record[columns[ZipCodeField]],
+		Address:   record[columns[AddressField]],
+	}
+
+	// Optional columns used by the merge survivorship rules
+	if column, ok := columns[SourceField]; ok {
+		contact.Source = record[column]
+	}
+	if column, ok := columns[UpdatedAtField]; ok {
+		contact.UpdatedAt = record[column]
+	}
+
+	return contact
+}
 
-	err := c.csv.WriteCSV(filePath, header, csvData)
+// writeRecords streams the values to the file, converting each one to a record only when it is written.
+// JSON files encode the values instead. The file is only replaced when every record was written.
+func writeRecords[T any](ctx context.Context, c contactRepository, filePath string, header []string, values iter.Seq[T], toRecord func(T) []string) error {
+	writer, err := c.records.NewRecordWriter(ctx, filePath, header)
 	if err != nil {
-		c.log.Errorf("Error writing to CSV file: %v", err)
+		c.log.Errorf("Error writing to file: %v", err)
+		return err
+	}
+
+	for value := range values {
+		if err = writer.Write(toRecord(value), value); err != nil {
+			writer.Abort()
+			c.log.Errorf("Error writing to file: %v", err)
+			return err
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		c.log.Errorf("Error writing to file: %v", err)
 		return err
 	}
 
 	return nil
 }
 
-func (c contactRepository) convertProcessOutputToCSV(outputs []ProcessOutput) (header []string, data [][]string) {
-	header = []string{"ContactIDSource", "ContactIDMatch", "Accuracy"}
-	for _, output := range outputs {
-		accuracy, err := MapLevelToAccuracy(int(output.AccuracyLevel))
-		if err != nil {
-			c.log.Errorf("Error converting process output to csv: %v", err)
-		}
+// WriteContactData writes the matches, when linking every match also tells the dataset of both contacts
+func (c contactRepository) WriteContactData(ctx context.Context, data iter.Seq[ProcessOutput]) error {
+	header := []string{"ContactIDSource", "ContactIDMatch", "Accuracy", "PhoneticMatch"}
+	if c.linking() {
+		header = append(header, "SourceSide", "MatchSide")
+	}
+	return writeRecords(ctx, c, c.paths.Output, header, data, c.processOutputRecord)
+}
 
-		record := []string{
-			output.ContactIDSource,
-			output.ContactIDMatch,
-			string(accuracy),
-		}
+func (c contactRepository) processOutputRecord(output ProcessOutput) []string {
+	accuracy, err := MapLevelToAccuracy(int(output.AccuracyLevel))
+	if err != nil {
+		c.log.Errorf("Error converting process output to csv: %v", err)
+	}
 
-		data = append(data, record)
+	record := []string{
+		output.ContactIDSource,
+		output.ContactIDMatch,
+		string(accuracy),
+		output.PhoneticMatch,
+	}
+	if c.linking() {
+		record = append(record, string(output.SourceSide), string(output.MatchSide))
 	}
+	return record
+}
 
-	return header, data
+func (c contactRepository) linking() bool {
+	return c.paths.Reference != ""
 }
 
-func (c contactRepository) WriteDuplicateContacts(data []Contact) error {
-	filePath := filepath.Join("files", "duplicate.csv")
-	header, csvData := c.convertProcessDuplicatesToCSV(data)
+func (c contactRepository) WriteDuplicateContacts(ctx context.Context, data iter.Seq[Contact]) error {
+	header := []string{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address"}
+	return writeRecords(ctx, c, c.paths.Duplicates, header, data, duplicateRecord)
+}
 
-	err := c.csv.WriteCSV(filePath, header, csvData)
-	if err != nil {
-		c.log.Errorf("Error writing to CSV file: %v", err)
-		return err
+func duplicateRecord(contact Contact) []string {
+	return []string{
+		contact.ContactID,
+		contact.FirstName,
+		contact.LastName,
+		contact.Email,
+		contact.ZipCode,
+		contact.Address,
 	}
+}
 
-	return nil
+func (c contactRepository) WriteClusters(ctx context.Context, assignments iter.Seq[ClusterAssignment]) error {
+	header := []string{"ContactID", "ClusterID"}
+	return writeRecords(ctx, c, c.paths.Clusters, header, assignments, clusterRecord)
+}
+
+func clusterRecord(assignment ClusterAssignment) []string {
+	return []string{
+		assignment.ContactID,
+		strconv.Itoa(assignment.ClusterID),
+	}
+}
+
+func (c contactRepository) WriteMergedContacts(ctx context.Context, contacts iter.Seq[Contact]) error {
+	header := []string{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address", "Source", "UpdatedAt"}
+	return writeRecords(ctx, c, c.paths.Merged, header, contacts, mergedRecord)
+}
+
+func mergedRecord(contact Contact) []string {
+	return []string{
+		contact.ContactID,
+		contact.FirstName,
+		contact.LastName,
+		contact.Email,
+		contact.ZipCode,
+		contact.Address,
+		contact.Source,
+		contact.UpdatedAt,
+	}
+}
+
+func (c contactRepository) WriteContactMapping(ctx context.Context, mapping iter.Seq[ContactMapping]) error {
+	header := []string{"ContactID", "SurvivingContactID"}
+	return writeRecords(ctx, c, c.paths.Mapping, header, mapping, mappingRecord)
+}
+
+func mappingRecord(contactMapping ContactMapping) []string {
+	return []string{
+		contactMapping.ContactID,
+		contactMapping.SurvivingContactID,
+	}
+}
+
+// WriteExplanations writes a row per field of every match, in the order of the matches file
+func (c contactRepository) WriteExplanations(ctx context.Context, data iter.Seq[ProcessOutput]) error {
+	header := []string{"ContactIDSource", "ContactIDMatch", "Accuracy", "Field", "Match", "Method", "Score"}
+	if c.linking() {
+		header = append(header, "SourceSide", "MatchSide")
+	}
+
+	return writeRecords(ctx, c, c.paths.Explanations, header, explanationRows(data), c.explanationRecord)
 }
 
-func (c contactRepository) convertProcessDuplicatesToCSV(contacts []Contact) (header []string, data [][]string) {
-	header = []string{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address"}
+// WriteQualityReport writes the number of values of each field by validity
+func (c contactRepository) WriteQualityReport(ctx context.Context, report []FieldQuality) error {
+	header := []string{"Field", "Values", "Valid", "Missing", "Placeholder", "Invalid"}
+	return writeRecords(ctx, c, c.paths.Quality, header, slices.Values(report), qualityRecord)
+}
+
+func qualityRecord(quality FieldQuality) []string {
+	return []string{
+		string(quality.Field),
+		strconv.Itoa(quality.Values),
+		strconv.Itoa(quality.Valid),
+		strconv.Itoa(quality.Missing),
+		strconv.Itoa(quality.Placeholder),
+		strconv.Itoa(quality.Invalid),
+	}
+}
 
-	for _, contact := range contacts {
-		record := []string{
-			contact.ContactID,
-			contact.FirstName,
-			contact.LastName,
-			contact.Email,
-			contact.ZipCode,
-			contact.Address,
+// Rejects lists the rows skipped by the last reading of the input and reference files
+func (c contactRepository) Rejects() []Reject {
+	return slices.Clone(*c.rejects)
+}
+
+// WriteRejects writes every rejected row as it was read, its fields joined back into a single CSV column
+func (c contactRepository) WriteRejects(ctx context.Context, rejects []Reject) error {
+	if c.paths.Rejects == "" {
+		return nil
+	}
+
+	header := []string{"Line", "Reason", "Record"}
+	if c.linking() {
+		header = append(header, "Dataset")
+	}
+	return writeRecords(ctx, c, c.paths.Rejects, header, slices.Values(rejects), c.rejectRecord)
+}
+
+func (c contactRepository) rejectRecord(reject Reject) []string {
+	record := []string{
+		strconv.Itoa(reject.Line),
+		reject.Reason,
+		joinRecord(reject.Record),
+	}
+	if c.linking() {
+		record = append(record, string(reject.Dataset))
+	}
+	return record
+}
+
+// joinRecord encodes the fields as a CSV line, without the line break
+func joinRecord(fields []string) string {
+	var line strings.Builder
+	writer := csv.NewWriter(&line)
+	writer.Write(fields)
+	writer.Flush()
+	return strings.TrimSuffix(line.String(), "\n")
+}
+
+// explanationRows yields every field explanation of the matches, in the order of the matches
+func explanationRows(data iter.Seq[ProcessOutput]) iter.Seq[explanationRow] {
+	return func(yield func(explanationRow) bool) {
+		for output := range data {
+			for _, explanation := range output.Explanation {
+				row := explanationRow{
+					ContactIDSource:  output.ContactIDSource,
+					ContactIDMatch:   output.ContactIDMatch,
+					SourceSide:       output.SourceSide,
+					MatchSide:        output.MatchSide,
+					AccuracyLevel:    output.AccuracyLevel,
+					FieldExplanation: explanation,
+				}
+				if !yield(row) {
+					return
+				}
+			}
 		}
-		data = append(data, record)
+	}
+}
+
+// explanationRow is a field of a match in the explanations file
+type explanationRow struct {
+	ContactIDSource string  `json:"contact_id_source"`
+	ContactIDMatch  string  `json:"contact_id_match"`
+	SourceSide      Dataset `json:"source_side,omitempty"`
+	MatchSide       Dataset `json:"match_side,omitempty"`
+	AccuracyLevel   int     `json:"accuracy"`
+	FieldExplanation
+}
+
+func (c contactRepository) explanationRecord(row explanationRow) []string {
+	accuracy, err := MapLevelToAccuracy(row.AccuracyLevel)
+	if err != nil {
+		c.log.Errorf("Error converting explanation to csv: %v", err)
 	}
 
-	return header, data
+	record := []string{
+		row.ContactIDSource,
+		row.ContactIDMatch,
+		string(accuracy),
+		string(row.Field),
+		string(row.Match),
+		row.Method,
+		strconv.FormatFloat(row.Score, 'f', 2, 64),
+	}
+	if c.linking() {
+		record = append(record, string(row.SourceSide), string(row.MatchSide))
+	}
+	return record
 }
@@ -1,13 +1,19 @@
 package contact_test
 
 import (
+	"context"
 	"errors"
-	"github.com/sebastianreh/internal/contact"
-	"github.com/sebastianreh/mocks"
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestContactRepository_GetContactData(t *testing.T) {
@@ -15,16 +21,16 @@ func TestContactRepository_GetContactData(t *testing.T) {
 
 	t.Run("when reading contact data successfully, it should return contacts", func(t *testing.T) {
 		mockCsv := new(mocks.CsvMock)
-		repo := contact.NewContactRepository(logger, mockCsv)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
 		mockedCSVData := [][]string{
 			{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address"},
 			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St"},
 			{"2", "Jane", "Smith", "jane@example.com", "54321", "456 Oak St"},
 		}
 
-		mockCsv.On("ReadCSV", "files/input.csv").Return(mockedCSVData, nil)
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(mockedCSVData, nil))
 
-		contacts, err := repo.GetContactData()
+		contacts, err := repo.GetContactData(context.Background())
 
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(contacts))
@@ -34,12 +40,145 @@ func TestContactRepository_GetContactData(t *testing.T) {
 		mockCsv.AssertExpectations(t)
 	})
 
+	t.Run("when the header has source and updated at columns, it should read them", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedCSVData := [][]string{
+			{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address", "Source", "Updated_At"},
+			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St", "crm", "2024-01-02"},
+		}
+
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(mockedCSVData, nil))
+
+		contacts, err := repo.GetContactData(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(contacts))
+		assert.Equal(t, "crm", contacts[0].Source)
+		assert.Equal(t, "2024-01-02", contacts[0].UpdatedAt)
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when the columns are reordered or extra, it should resolve them by header name", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedCSVData := [][]string{
+			{"email", "phone", "address", "name1", "postalZip", "name", "contactID"},
+			{"john@example.com", "555-1234", "123 Main St", "Doe", "12345", "John", "1"},
+		}
+
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(mockedCSVData, nil))
+
+		contacts, err := repo.GetContactData(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+		}, contacts)
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when a custom mapping is given, it should read the mapped columns", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		columns := contact.DefaultColumnMapping()
+		columns[contact.FirstNameField] = []string{"forename"}
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), columns)
+		mockedCSVData := [][]string{
+			{"id", "forename", "surname", "email", "zip", "address"},
+			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St"},
+		}
+
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(mockedCSVData, nil))
+
+		contacts, err := repo.GetContactData(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, "John", contacts[0].FirstName)
+		assert.Equal(t, "Doe", contacts[0].LastName)
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when required columns are missing, it should return an error listing them", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedCSVData := [][]string{
+			{"contactID", "name", "name1", "address"},
+			{"1", "John", "Doe", "123 Main St"},
+		}
+
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(mockedCSVData, nil))
+
+		_, err := repo.GetContactData(context.Background())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.MissingColumnError+": email, zip_code", err.Error())
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when a record has a different number of fields than the header, it should skip it", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedCSVData := [][]string{
+			{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address"},
+			{"1", "John", "Doe", "john@example.com", "12345"},
+			{"2", "Jane", "Smith", "jane@example.com", "54321", "456 Oak St"},
+		}
+
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(mockedCSVData, nil))
+
+		contacts, err := repo.GetContactData(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(contacts))
+		assert.Equal(t, "2", contacts[0].ContactID)
+		assert.Equal(t, []contact.Reject{
+			{Line: 2, Reason: "record has 5 fields, the header has 6", Record: []string{"1", "John", "Doe", "john@example.com", "12345"}},
+		}, repo.(contact.Rejecting).Rejects())
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when the file is empty, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(nil, nil))
+
+		_, err := repo.GetContactData(context.Background())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.NoRecordsError, err.Error())
+
+		mockCsv.AssertExpectations(t)
+	})
+
+	t.Run("when the CSV read fails after some records, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedCSVData := [][]string{
+			{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address"},
+			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St"},
+		}
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(mockedCSVData, errors.New("failed to read CSV")))
+
+		contacts, err := repo.GetContactData(context.Background())
+
+		assert.Nil(t, contacts)
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to read CSV", err.Error())
+
+		mockCsv.AssertExpectations(t)
+	})
+
 	t.Run("when CSV read fails, it should return an error", func(t *testing.T) {
 		mockCsv := new(mocks.CsvMock)
-		repo := contact.NewContactRepository(logger, mockCsv)
-		mockCsv.On("ReadCSV", "files/input.csv").Return([][]string{}, errors.New("failed to read CSV"))
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockCsv.On("StreamCSV", mock.Anything, "files/input.csv").Return(mocks.CSVRows(nil, errors.New("failed to read CSV")))
 
-		_, err := repo.GetContactData()
+		_, err := repo.GetContactData(context.Background())
 
 		assert.NotNil(t, err)
 		assert.Equal(t, "failed to read CSV", err.Error())
@@ -48,46 +187,96 @@ func TestContactRepository_GetContactData(t *testing.T) {
 	})
 }
 
+func TestContactRepository_GetReferenceData(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("when a reference file is configured, it should read its contacts with the same columns", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		paths := contact.DefaultPaths()
+		paths.Reference = "files/crm.csv"
+		repo := contact.NewContactRepository(logger, mockCsv, paths, contact.DefaultColumnMapping())
+		mockedCSVData := [][]string{
+			{"contactID", "name", "name1", "email", "postalZip", "address"},
+			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St"},
+		}
+
+		mockCsv.On("StreamCSV", mock.Anything, "files/crm.csv").Return(mocks.CSVRows(mockedCSVData, nil))
+
+		contacts, err := repo.GetReferenceData(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+		}, contacts)
+
+		mockCsv.AssertExpectations(t)
+	})
+}
+
 func TestContactRepository_WriteContactData(t *testing.T) {
 	logger := logrus.New()
 
 	t.Run("when writing contact data successfully, it should not return an error", func(t *testing.T) {
 		mockCsv := new(mocks.CsvMock)
-		repo := contact.NewContactRepository(logger, mockCsv)
-		mockedHeader := []string{"ContactIDSource", "ContactIDMatch", "Accuracy"}
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactIDSource", "ContactIDMatch", "Accuracy", "PhoneticMatch"}
+		mockedData := [][]string{
+			{"1", "2", "High", "last_name:soundex"},
+		}
+		writer := expectCSVWriter(mockCsv, "files/output.csv", mockedHeader, mockedData, nil)
+
+		output := []contact.ProcessOutput{
+			{ContactIDSource: "1", ContactIDMatch: "2", AccuracyLevel: 4, PhoneticMatch: "last_name:soundex"},
+		}
+
+		err := repo.WriteContactData(context.Background(), slices.Values(output))
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("when linking to a reference file, it should write the dataset of both contacts", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		paths := contact.DefaultPaths()
+		paths.Reference = "files/crm.csv"
+		repo := contact.NewContactRepository(logger, mockCsv, paths, contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactIDSource", "ContactIDMatch", "Accuracy", "PhoneticMatch", "SourceSide", "MatchSide"}
 		mockedData := [][]string{
-			{"1", "2", "High"},
+			{"1", "1", "Very High", "", "source", "reference"},
 		}
-		mockCsv.On("WriteCSV", "files/output.csv", mockedHeader, mockedData).Return(nil)
+		writer := expectCSVWriter(mockCsv, "files/output.csv", mockedHeader, mockedData, nil)
 
 		output := []contact.ProcessOutput{
-			{ContactIDSource: "1", ContactIDMatch: "2", AccuracyLevel: 4},
+			{ContactIDSource: "1", ContactIDMatch: "1", AccuracyLevel: 5, SourceSide: contact.SourceDataset, MatchSide: contact.ReferenceDataset},
 		}
 
-		err := repo.WriteContactData(output)
+		err := repo.WriteContactData(context.Background(), slices.Values(output))
 		assert.Nil(t, err)
 
 		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
 	})
 
 	t.Run("when writing contact data fails, it should return an error", func(t *testing.T) {
 		mockCsv := new(mocks.CsvMock)
-		repo := contact.NewContactRepository(logger, mockCsv)
-		mockedHeader := []string{"ContactIDSource", "ContactIDMatch", "Accuracy"}
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactIDSource", "ContactIDMatch", "Accuracy", "PhoneticMatch"}
 		mockedData := [][]string{
-			{"1", "2", "High"},
+			{"1", "2", "High", "last_name:soundex"},
 		}
-		mockCsv.On("WriteCSV", "files/output.csv", mockedHeader, mockedData).Return(errors.New("failed to write CSV"))
+		writer := expectCSVWriter(mockCsv, "files/output.csv", mockedHeader, mockedData, errors.New("failed to write CSV"))
 
 		output := []contact.ProcessOutput{
-			{ContactIDSource: "1", ContactIDMatch: "2", AccuracyLevel: 4},
+			{ContactIDSource: "1", ContactIDMatch: "2", AccuracyLevel: 4, PhoneticMatch: "last_name:soundex"},
 		}
 
-		err := repo.WriteContactData(output)
+		err := repo.WriteContactData(context.Background(), slices.Values(output))
 		assert.NotNil(t, err)
 		assert.Equal(t, "failed to write CSV", err.Error())
 
 		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
 	})
 }
 
@@ -96,40 +285,386 @@ func TestContactRepository_WriteDuplicateContacts(t *testing.T) {
 
 	t.Run("when writing duplicate contacts successfully, it should not return an error", func(t *testing.T) {
 		mockCsv := new(mocks.CsvMock)
-		repo := contact.NewContactRepository(logger, mockCsv)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
 		mockedHeader := []string{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address"}
 		mockedData := [][]string{
 			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St"},
 		}
-		mockCsv.On("WriteCSV", "files/duplicate.csv", mockedHeader, mockedData).Return(nil)
+		writer := expectCSVWriter(mockCsv, "files/duplicate.csv", mockedHeader, mockedData, nil)
 
 		duplicates := []contact.Contact{
 			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
 		}
 
-		err := repo.WriteDuplicateContacts(duplicates)
+		err := repo.WriteDuplicateContacts(context.Background(), slices.Values(duplicates))
 		assert.Nil(t, err)
 
 		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
 	})
 
 	t.Run("when writing duplicate contacts fails, it should return an error", func(t *testing.T) {
 		mockCsv := new(mocks.CsvMock)
-		repo := contact.NewContactRepository(logger, mockCsv)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
 		mockedHeader := []string{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address"}
 		mockedData := [][]string{
 			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St"},
 		}
-		mockCsv.On("WriteCSV", "files/duplicate.csv", mockedHeader, mockedData).Return(errors.New("failed to write CSV"))
+		writer := expectCSVWriter(mockCsv, "files/duplicate.csv", mockedHeader, mockedData, errors.New("failed to write CSV"))
 
 		duplicates := []contact.Contact{
 			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
 		}
 
-		err := repo.WriteDuplicateContacts(duplicates)
+		err := repo.WriteDuplicateContacts(context.Background(), slices.Values(duplicates))
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to write CSV", err.Error())
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+}
+
+func TestContactRepository_WriteClusters(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("when writing clusters successfully, it should not return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactID", "ClusterID"}
+		mockedData := [][]string{
+			{"1", "1"},
+			{"2", "1"},
+		}
+		writer := expectCSVWriter(mockCsv, "files/clusters.csv", mockedHeader, mockedData, nil)
+
+		assignments := []contact.ClusterAssignment{
+			{ContactID: "1", ClusterID: 1},
+			{ContactID: "2", ClusterID: 1},
+		}
+
+		err := repo.WriteClusters(context.Background(), slices.Values(assignments))
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("when writing clusters fails, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactID", "ClusterID"}
+		mockedData := [][]string{
+			{"1", "1"},
+		}
+		writer := expectCSVWriter(mockCsv, "files/clusters.csv", mockedHeader, mockedData, errors.New("failed to write CSV"))
+
+		err := repo.WriteClusters(context.Background(), slices.Values([]contact.ClusterAssignment{{ContactID: "1", ClusterID: 1}}))
 		assert.NotNil(t, err)
 		assert.Equal(t, "failed to write CSV", err.Error())
 
 		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+}
+
+func TestContactRepository_WriteMergedContacts(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("when writing merged contacts successfully, it should not return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactID", "FirstName", "LastName", "Email", "ZipCode", "Address", "Source", "UpdatedAt"}
+		mockedData := [][]string{
+			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St", "crm", "2024-01-02"},
+		}
+		writer := expectCSVWriter(mockCsv, "files/merged.csv", mockedHeader, mockedData, nil)
+
+		merged := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St", Source: "crm", UpdatedAt: "2024-01-02"},
+		}
+
+		err := repo.WriteMergedContacts(context.Background(), slices.Values(merged))
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("when writing merged contacts fails, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockCsv.On("NewCSVWriter", mock.Anything, "files/merged.csv", mock.Anything).Return(nil, errors.New("failed to write CSV"))
+
+		err := repo.WriteMergedContacts(context.Background(), slices.Values([]contact.Contact{{ContactID: "1"}}))
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to write CSV", err.Error())
+
+		mockCsv.AssertExpectations(t)
+	})
+}
+
+func TestContactRepository_WriteContactMapping(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("when writing the contact mapping successfully, it should not return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactID", "SurvivingContactID"}
+		mockedData := [][]string{
+			{"1", "1"},
+			{"2", "1"},
+		}
+		writer := expectCSVWriter(mockCsv, "files/mapping.csv", mockedHeader, mockedData, nil)
+
+		mapping := []contact.ContactMapping{
+			{ContactID: "1", SurvivingContactID: "1"},
+			{ContactID: "2", SurvivingContactID: "1"},
+		}
+
+		err := repo.WriteContactMapping(context.Background(), slices.Values(mapping))
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("when writing the contact mapping fails, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockCsv.On("NewCSVWriter", mock.Anything, "files/mapping.csv", mock.Anything).Return(nil, errors.New("failed to write CSV"))
+
+		err := repo.WriteContactMapping(context.Background(), slices.Values([]contact.ContactMapping{{ContactID: "1", SurvivingContactID: "1"}}))
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to write CSV", err.Error())
+
+		mockCsv.AssertExpectations(t)
+	})
+}
+
+func TestContactRepository_WriteExplanations(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("when writing explanations successfully, it should write a row per field of every match", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"ContactIDSource", "ContactIDMatch", "Accuracy", "Field", "Match", "Method", "Score"}
+		mockedData := [][]string{
+			{"1", "85", "Low", "first_name", "phonetic", "double_metaphone", "1.00"},
+			{"1", "85", "Low", "email", "similar", "damerau_levenshtein", "0.93"},
+		}
+		writer := expectCSVWriter(mockCsv, "files/explanations.csv", mockedHeader, mockedData, nil)
+
+		outputs := []contact.ProcessOutput{
+			{ContactIDSource: "1", ContactIDMatch: "85", AccuracyLevel: 2, Explanation: []contact.FieldExplanation{
+				{Field: contact.FirstNameField, Match: contact.PhoneticFieldMatch, Method: "double_metaphone", Score: 1},
+				{Field: contact.EmailField, Match: contact.SimilarFieldMatch, Method: "damerau_levenshtein", Score: 0.9333},
+			}},
+		}
+
+		err := repo.WriteExplanations(context.Background(), slices.Values(outputs))
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("when writing explanations fails, it should return an error", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockCsv.On("NewCSVWriter", mock.Anything, "files/explanations.csv", mock.Anything).Return(nil, errors.New("failed to write CSV"))
+
+		err := repo.WriteExplanations(context.Background(), nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to write CSV", err.Error())
+
+		mockCsv.AssertExpectations(t)
+	})
+}
+
+func TestContactRepository_WriteQualityReport(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("when writing the data quality report, it should write a row per field", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"Field", "Values", "Valid", "Missing", "Placeholder", "Invalid"}
+		mockedData := [][]string{
+			{"email", "10", "6", "1", "2", "1"},
+		}
+		writer := expectCSVWriter(mockCsv, "files/data_quality.csv", mockedHeader, mockedData, nil)
+
+		report := []contact.FieldQuality{{Field: contact.EmailField, Values: 10, Valid: 6, Missing: 1, Placeholder: 2, Invalid: 1}}
+		err := repo.WriteQualityReport(context.Background(), report)
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+}
+
+func TestContactRepository_WriteRejects(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("when writing the rejected rows, it should join the fields of each row into a CSV column", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.DefaultPaths(), contact.DefaultColumnMapping())
+		mockedHeader := []string{"Line", "Reason", "Record"}
+		mockedData := [][]string{
+			{"3", "record has 2 fields, the header has 6", `7,"Doe, John"`},
+		}
+		writer := expectCSVWriter(mockCsv, "files/rejects.csv", mockedHeader, mockedData, nil)
+
+		rejects := []contact.Reject{{Line: 3, Reason: "record has 2 fields, the header has 6", Record: []string{"7", "Doe, John"}}}
+		err := repo.(contact.Rejecting).WriteRejects(context.Background(), rejects)
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("when linking, it should write the dataset of each row", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		paths := contact.DefaultPaths()
+		paths.Reference = "files/crm.csv"
+		repo := contact.NewContactRepository(logger, mockCsv, paths, contact.DefaultColumnMapping())
+		mockCsv.On("StreamCSV", mock.Anything, "files/crm.csv").Return(mocks.CSVRows([][]string{
+			{"contactID", "name", "name1", "email", "postalZip", "address"},
+			{"1", "John", "Doe", "john@example.com", "12345", "123 Main St"},
+			{"2", "Jane"},
+		}, nil))
+		writer := expectCSVWriter(mockCsv, "files/rejects.csv", []string{"Line", "Reason", "Record", "Dataset"}, [][]string{
+			{"3", "record has 2 fields, the header has 6", "2,Jane", "reference"},
+		}, nil)
+
+		_, err := repo.GetReferenceData(context.Background())
+		assert.Nil(t, err)
+
+		rejecting := repo.(contact.Rejecting)
+		err = rejecting.WriteRejects(context.Background(), rejecting.Rejects())
+		assert.Nil(t, err)
+
+		mockCsv.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("when the rejects path is empty, it should not write any file", func(t *testing.T) {
+		mockCsv := new(mocks.CsvMock)
+		repo := contact.NewContactRepository(logger, mockCsv, contact.Paths{Input: "files/input.csv"}, contact.DefaultColumnMapping())
+
+		err := repo.(contact.Rejecting).WriteRejects(context.Background(), []contact.Reject{{Line: 1}})
+		assert.Nil(t, err)
+
+		mockCsv.AssertNotCalled(t, "NewCSVWriter", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// expectCSVWriter expects the records to be written one at a time and the file to be closed.
+// When writeErr is not nil every write fails with it and the file is aborted instead.
+func expectCSVWriter(mockCsv *mocks.CsvMock, filePath string, header []string, data [][]string, writeErr error) *mocks.CsvWriterMock {
+	writer := new(mocks.CsvWriterMock)
+	mockCsv.On("NewCSVWriter", mock.Anything, filePath, header).Return(writer, nil)
+	for _, record := range data {
+		writer.On("Write", record).Return(writeErr)
+	}
+
+	if writeErr != nil {
+		writer.On("Abort").Return(nil)
+	} else {
+		writer.On("Close").Return(nil)
+	}
+	return writer
+}
+
+func TestContactRepository_JSONFormats(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	t.Run("when the input is NDJSON, it should read the contacts by the keys of every object", func(t *testing.T) {
+		dir := t.TempDir()
+		paths := contact.FormatOutputPaths(filepath.Join(dir, "input.ndjson"), dir, pkg.JSONFormat)
+		assert.Nil(t, os.WriteFile(paths.Input, []byte(
+			`{"contact_id":1,"first_name":"John","last_name":"Doe","email":"john@example.com","zip_code":"12345"}`+"\n"+
+				`{"contact_id":2,"first_name":"Jane","last_name":"Smith","email":"jane@example.com","zip_code":"54321","address":"456 Oak St","source":"crm"}`+"\n"), 0o644))
+		repo := contact.NewContactRepository(logger, pkg.NewCSVConnector(), paths, nil)
+
+		contacts, err := repo.GetContactData(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345"},
+			{ContactID: "2", FirstName: "Jane", LastName: "Smith", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St", Source: "crm"},
+		}, contacts)
+	})
+
+	t.Run("when the JSON input has no objects, it should return an error", func(t *testing.T) {
+		dir := t.TempDir()
+		paths := contact.FormatOutputPaths(filepath.Join(dir, "input.json"), dir, pkg.JSONFormat)
+		assert.Nil(t, os.WriteFile(paths.Input, []byte("[]"), 0o644))
+		repo := contact.NewContactRepository(logger, pkg.NewCSVConnector(), paths, nil)
+
+		_, err := repo.GetContactData(ctx)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.NoRecordsError, err.Error())
+	})
+
+	t.Run("when rows can't be parsed, it should reject them on the line they start and read the rest", func(t *testing.T) {
+		dir := t.TempDir()
+		paths := contact.FormatOutputPaths(filepath.Join(dir, "input.csv"), dir, pkg.CSVFormat)
+		assert.Nil(t, os.WriteFile(paths.Input, []byte("contactID,name,name1,email,postalZip,address\n"+
+			"1,John,Doe,john@example.com,12345,\"123 Main St\nApt 4\"\n"+
+			"2,Jo\"e,Smith,jo@example.com,54321,456 Oak St\n"+
+			"3,Jane,Smith,jane@example.com,54321\n"+
+			"4,Ann,Lee,ann@example.com,11111,789 Pine St\n"), 0o644))
+		repo := contact.NewContactRepository(logger, pkg.NewCSVConnector(), paths, nil)
+
+		contacts, err := repo.GetContactData(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1", "4"}, []string{contacts[0].ContactID, contacts[1].ContactID})
+		assert.Equal(t, []contact.Reject{
+			{Line: 4, Reason: `bare " in non-quoted-field`},
+			{Line: 5, Reason: "record has 5 fields, the header has 6", Record: []string{"3", "Jane", "Smith", "jane@example.com", "54321"}},
+		}, repo.(contact.Rejecting).Rejects())
+	})
+
+	t.Run("when a JSON object has a nested value, it should reject it and read the rest", func(t *testing.T) {
+		dir := t.TempDir()
+		paths := contact.FormatOutputPaths(filepath.Join(dir, "input.ndjson"), dir, pkg.JSONFormat)
+		assert.Nil(t, os.WriteFile(paths.Input, []byte(
+			`{"contact_id":1,"first_name":"John","last_name":"Doe","email":["john@example.com"],"zip_code":"12345","address":"123 Main St"}`+"\n"+
+				`{"contact_id":2,"first_name":"Jane","last_name":"Smith","email":"jane@example.com","zip_code":"54321","address":"456 Oak St"}`+"\n"), 0o644))
+		repo := contact.NewContactRepository(logger, pkg.NewCSVConnector(), paths, nil)
+
+		contacts, err := repo.GetContactData(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(contacts))
+		assert.Equal(t, "2", contacts[0].ContactID)
+		assert.Equal(t, []contact.Reject{{Line: 1, Reason: `unsupported value of key "email"`}}, repo.(contact.Rejecting).Rejects())
+	})
+
+	t.Run("when the outputs are JSON, it should encode the values with their json tags", func(t *testing.T) {
+		dir := t.TempDir()
+		paths := contact.FormatOutputPaths(filepath.Join(dir, "input.csv"), dir, pkg.JSONFormat)
+		repo := contact.NewContactRepository(logger, pkg.NewCSVConnector(), paths, nil)
+		outputs := []contact.ProcessOutput{
+			{ContactIDSource: "1", ContactIDMatch: "2", AccuracyLevel: 4, Explanation: []contact.FieldExplanation{
+				{Field: contact.EmailField, Match: contact.ExactFieldMatch, Score: 1},
+			}},
+		}
+
+		assert.Nil(t, repo.WriteContactData(ctx, slices.Values(outputs)))
+		assert.Nil(t, repo.WriteExplanations(ctx, slices.Values(outputs)))
+
+		var written []contact.ProcessOutput
+		assert.Nil(t, pkg.NewJSONConnector().ReadJSON(ctx, paths.Output, &written))
+		assert.Equal(t, outputs, written)
+
+		explanations, err := os.ReadFile(paths.Explanations)
+		assert.Nil(t, err)
+		assert.JSONEq(t, `[{"contact_id_source":"1","contact_id_match":"2","accuracy":4,"field":"email","match":"exact","score":1}]`,
+			string(explanations))
 	})
 }
@@ -0,0 +1,353 @@
+package contact
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	InvalidWeightError = "invalid field weight"
+)
+
+// defaultAccuracyCutoffs reach each accuracy level with one more point, the integer part of the score
+var defaultAccuracyCutoffs = AccuracyCutoffs{1, 2, 3, 4, 5}
+
+// fieldValues holds the values of a field for the two compared contacts
+type fieldValues struct {
+	field      Field
+	value1     string
+	value2     string
+	exact      bool
+	similarity float64
+}
+
+// record is a contact prepared for scoring. Normalized values and phonetic keys are computed once per contact
+// instead of once per compared pair, while the contact keeps its original values for the outputs.
+type record struct {
+	Contact
+	// dataset is the side of the linkage the contact comes from, empty when deduplicating a single dataset
+	dataset Dataset
+	// invalid lists the fields whose values were dropped by validation, the compared values are empty instead
+	invalid       []Field
+	firstName     string
+	lastName      string
+	email         string
+	zipCode       string
+	address       string
+	parsedAddress Address
+	firstNameKeys phoneticKeys
+	lastNameKeys  phoneticKeys
+}
+
+// comparison is the outcome of scoring two contacts
+type comparison struct {
+	accuracyLevel int
+	duplicate     bool
+	// phoneticMatch lists the encoders that recognised the names as alike, e.g. "last_name:double_metaphone"
+	phoneticMatch string
+}
+
+// match is a compared pair of contacts that matched or are duplicates
+type match struct {
+	pair
+	comparison
+}
+
+// pairScorer scores two prepared contacts, it is shared by the workers so it must be safe for concurrent use
+type pairScorer interface {
+	score(r1, r2 record) comparison
+	explain(r1, r2 record) []FieldExplanation
+}
+
+type scorer struct {
+	// weights are the weights of the compared fields, in the order of comparedFields
+	weights          [len(comparedFields)]float64
+	accuracyCutoffs  AccuracyCutoffs
+	comparators      map[Field]FieldComparator
+	phoneticEncoders []PhoneticEncoder
+	nicknames        *NicknameTable
+	normalizeEmails  bool
+	parseAddresses   bool
+	validator        validator
+}
+
+func newScorer(config Config) (scorer, error) {
+	if err := validateScoringModel(config.ScoringModel); err != nil {
+		return scorer{}, err
+	}
+
+	if err := config.FellegiSunter.validate(); err != nil {
+		return scorer{}, err
+	}
+
+	weights, err := fieldWeights(config.Weights)
+	if err != nil {
+		return scorer{}, err
+	}
+
+	if err = config.AccuracyCutoffs.validate(); err != nil {
+		return scorer{}, err
+	}
+
+	accuracyCutoffs := config.AccuracyCutoffs
+	if len(accuracyCutoffs) == 0 {
+		accuracyCutoffs = defaultAccuracyCutoffs
+	}
+
+	for field, comparator := range config.Comparators {
+		if err := comparator.validate(); err != nil {
+			return scorer{}, fmt.Errorf("field %s: %w", field, err)
+		}
+	}
+
+	if err := validatePhoneticEncoders(config.PhoneticEncoders); err != nil {
+		return scorer{}, err
+	}
+
+	validator, err := newValidator(config.Validation)
+	if err != nil {
+		return scorer{}, err
+	}
+
+	return scorer{
+		weights:          weights,
+		accuracyCutoffs:  accuracyCutoffs,
+		comparators:      config.Comparators,
+		phoneticEncoders: config.PhoneticEncoders,
+		nicknames:        config.Nicknames,
+		normalizeEmails:  config.NormalizeEmails,
+		parseAddresses:   config.ParseAddresses,
+		validator:        validator,
+	}, nil
+}
+
+func (s scorer) prepare(contact Contact) record {
+	invalid := s.validator.invalidFields(contact)
+	compared := dropInvalid(contact, invalid)
+	prepared := record{
+		Contact:   contact,
+		invalid:   invalid,
+		firstName: compared.FirstName,
+		lastName:  compared.LastName,
+		email:     compared.Email,
+		zipCode:   compared.ZipCode,
+		address:   compared.Address,
+	}
+
+	// Emails are compared by the mailbox they deliver to
+	if s.normalizeEmails {
+		prepared.email = CanonicalEmail(compared.Email)
+	}
+
+	// Addresses are compared in their normalized form and, when they differ, component by component
+	if s.parseAddresses {
+		prepared.parsedAddress = ParseAddress(compared.Address)
+		prepared.address = prepared.parsedAddress.String()
+	}
+
+	if len(s.phoneticEncoders) > 0 {
+		prepared.firstNameKeys = encodePhonetic(s.phoneticEncoders, compared.FirstName)
+		prepared.lastNameKeys = encodePhonetic(s.phoneticEncoders, compared.LastName)
+	}
+
+	return prepared
+}
+
+func (s scorer) prepareAll(contacts []Contact) []record {
+	records := make([]record, len(contacts))
+	for i, contact := range contacts {
+		records[i] = s.prepare(contact)
+	}
+	return records
+}
+
+func (s scorer) score(r1, r2 record) comparison {
+	return s.calculateAccuracy(r1, r2)
+}
+
+func (s scorer) calculateAccuracy(r1, r2 record) comparison {
+	return s.scoreFields(r1, r2, nil)
+}
+
+// explain scores both contacts again, breaking the score down by field
+func (s scorer) explain(r1, r2 record) []FieldExplanation {
+	explanations := make([]FieldExplanation, len(comparedFields))
+	s.scoreFields(r1, r2, explanations)
+	return explanations
+}
+
+// scoreFields adds up the credit of every field, filling the explanation of each field when explanations isn't nil
+func (s scorer) scoreFields(r1, r2 record, explanations []FieldExplanation) comparison {
+	var score float64
+	var exactMatches int
+
+	fieldsToCompare := [...]fieldValues{
+		{field: FirstNameField, value1: r1.firstName, value2: r2.firstName},
+		{field: LastNameField, value1: r1.lastName, value2: r2.lastName},
+		{field: EmailField, value1: r1.email, value2: r2.email},
+		{field: ZipCodeField, value1: r1.zipCode, value2: r2.zipCode},
+		{field: AddressField, value1: r1.address, value2: r2.address},
+	}
+
+	for i, fields := range fieldsToCompare {
+		if compareAndAddScore(fields.value1, fields.value2, s.weights[i], &score) {
+			exactMatches++
+			fieldsToCompare[i].exact = true
+			if explanations != nil {
+				explanations[i] = FieldExplanation{Field: fields.field, Match: ExactFieldMatch, Score: s.weights[i]}
+			}
+			continue
+		}
+
+		fieldsToCompare[i].similarity = s.compareSimilarity(fields)
+	}
+
+	if exactMatches == len(fieldsToCompare) {
+		return comparison{duplicate: true}
+	}
+
+	// Since there is no logic defined for the accuracy score, I decided that if there is no match in any of fields, the value of both names matching should be 0,5
+	// so it doesn't generate too much very low accuracy data. Otherwise, it should add 1, since there is some more probably of being the same contact.
+	var firstLetterAddValue float64
+	if score == 0 {
+		firstLetterAddValue = 0.5
+	} else {
+		firstLetterAddValue = 1
+	}
+
+	// Fields that are not an exact match get their partial credit, names keep the best of the similarity and the soft name credit
+	var phoneticMatches []string
+	for i, fields := range fieldsToCompare {
+		if fields.exact {
+			continue
+		}
+
+		credit := fields.similarity
+		explanation := FieldExplanation{Field: fields.field, Match: NoFieldMatch}
+		if credit > 0 {
+			explanation.Match, explanation.Method = SimilarFieldMatch, string(s.comparators[fields.field].Comparator)
+		}
+
+		if isNameField(fields.field) && fields.value1 != fields.value2 {
+			keys1, keys2 := r1.firstNameKeys, r2.firstNameKeys
+			if fields.field == LastNameField {
+				keys1, keys2 = r1.lastNameKeys, r2.lastNameKeys
+			}
+
+			nameCredit, encoder := s.compareNames(fields.value1, fields.value2, keys1, keys2, firstLetterAddValue)
+			if nameCredit > credit {
+				credit = nameCredit
+				explanation.Match, explanation.Method = FirstLetterFieldMatch, ""
+				if encoder != "" {
+					explanation.Match, explanation.Method = PhoneticFieldMatch, string(encoder)
+				}
+			}
+		}
+
+		if fields.field == AddressField && s.parseAddresses {
+			if componentCredit := r1.parsedAddress.componentSimilarity(r2.parsedAddress); componentCredit > credit {
+				credit = componentCredit
+				explanation.Match, explanation.Method = AddressComponentsFieldMatch, ""
+			}
+		}
+
+		// A nickname of the same given name counts as a first name match
+		if fields.field == FirstNameField && s.nicknames.Equivalent(fields.value1, fields.value2) {
+			credit = 1
+			explanation.Match, explanation.Method = NicknameFieldMatch, ""
+		}
+
+		// Only the encoders that gave the credit of the field are exposed, as the explanation does
+		if explanation.Match == PhoneticFieldMatch {
+			phoneticMatches = append(phoneticMatches, fmt.Sprintf("%s:%s", fields.field, explanation.Method))
+		}
+
+		score += credit * s.weights[i]
+
+		if explanations != nil {
+			if credit == 0 && (fields.value1 == "" || fields.value2 == "") {
+				explanation.Match = MissingFieldMatch
+			}
+			explanation.Score = credit * s.weights[i]
+			explanations[i] = explanation
+		}
+	}
+
+	return comparison{
+		accuracyLevel: s.accuracyCutoffs.level(score),
+		phoneticMatch: strings.Join(phoneticMatches, ";"),
+	}
+}
+
+// compareNames gives the soft name credit, when phonetic encoders are configured they replace the first letter check
+func (s scorer) compareNames(name1, name2 string, keys1, keys2 phoneticKeys, addValue float64) (float64, PhoneticEncoder) {
+	if len(s.phoneticEncoders) == 0 {
+		return compareFirstLetter(name1, name2, addValue), ""
+	}
+
+	if encoder, ok := matchPhonetic(s.phoneticEncoders, keys1, keys2); ok {
+		return addValue, encoder
+	}
+
+	return 0, ""
+}
+
+// compareSimilarity returns the partial credit of a field that has a comparator configured
+func (s scorer) compareSimilarity(fields fieldValues) float64 {
+	comparator, ok := s.comparators[fields.field]
+	if !ok || len(strings.TrimSpace(fields.value1)) <= 1 || len(strings.TrimSpace(fields.value2)) <= 1 {
+		return 0
+	}
+
+	return comparator.similarity(fields.value1, fields.value2)
+}
+
+// fieldWeights orders the configured weights as comparedFields, fields without a weight weigh 1
+func fieldWeights(configured map[Field]float64) ([len(comparedFields)]float64, error) {
+	var weights [len(comparedFields)]float64
+	for i, field := range comparedFields {
+		weights[i] = 1
+		if weight, ok := configured[field]; ok {
+			weights[i] = weight
+		}
+	}
+
+	for field, weight := range configured {
+		if !slices.Contains(comparedFields[:], field) {
+			return weights, fmt.Errorf("%s: %q is not a compared field", InvalidWeightError, field)
+		}
+
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return weights, fmt.Errorf("%s: %v for field %s, it must be 0 or greater", InvalidWeightError, weight, field)
+		}
+	}
+
+	return weights, nil
+}
+
+func isNameField(field Field) bool {
+	return field == FirstNameField || field == LastNameField
+}
+
+func compareFirstLetter(name1, name2 string, addValue float64) float64 {
+	if len(name1) > 0 && len(name2) > 0 {
+		field1 := name1[:1]
+		field2 := name2[:1]
+		if field1 == field2 {
+			return addValue
+		}
+	}
+	return 0
+}
+
+func compareAndAddScore(field1, field2 string, weight float64, score *float64) bool {
+	if len(field1) > 1 && len(field2) > 1 {
+		if field1 == field2 {
+			*score += weight
+			return true
+		}
+	}
+	return false
+}
@@ -1,160 +1,513 @@
 package contact
 
 import (
+	"context"
+	"iter"
+	"slices"
+
 	"github.com/sirupsen/logrus"
 )
 
 type Service interface {
-	Evaluate() ([]ProcessOutput, error)
+	Evaluate(ctx context.Context) (iter.Seq[ProcessOutput], error)
+	Match(ctx context.Context, contact Contact) ([]Candidate, error)
 }
 
+// Repository reads the contacts and writes the outputs of a run, the outputs are streamed so the writers only hold
+// the value being written
 type Repository interface {
-	GetContactData() ([]Contact, error)
-	WriteContactData(data []ProcessOutput) error
-	WriteDuplicateContacts(duplicate []Contact) error
+	GetContactData(ctx context.Context) ([]Contact, error)
+	GetReferenceData(ctx context.Context) ([]Contact, error)
+	WriteContactData(ctx context.Context, data iter.Seq[ProcessOutput]) error
+	WriteDuplicateContacts(ctx context.Context, duplicate iter.Seq[Contact]) error
+	WriteClusters(ctx context.Context, assignments iter.Seq[ClusterAssignment]) error
+	WriteMergedContacts(ctx context.Context, contacts iter.Seq[Contact]) error
+	WriteContactMapping(ctx context.Context, mapping iter.Seq[ContactMapping]) error
+	WriteExplanations(ctx context.Context, data iter.Seq[ProcessOutput]) error
+	WriteQualityReport(ctx context.Context, report []FieldQuality) error
+}
+
+// Transactional is implemented by the repositories writing every output of a run at once, so a failed run leaves
+// the outputs of the previous one
+type Transactional interface {
+	Begin(ctx context.Context) error
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
 }
 
 type contactService struct {
 	log        *logrus.Logger
 	repository Repository
+	config     Config
+	matcher    *lazyMatcher
 }
 
-func NewContactService(log *logrus.Logger, repository Repository) Service {
+func NewContactService(log *logrus.Logger, repository Repository, config Config) Service {
 	return &contactService{
 		log:        log,
 		repository: repository,
+		config:     config,
+		matcher:    &lazyMatcher{},
 	}
 }
 
-func (c contactService) Evaluate() ([]ProcessOutput, error) {
-	contacts, err := c.repository.GetContactData()
+// Evaluate writes every output of the run and returns the matches, built from the scored pairs as they are iterated
+func (c contactService) Evaluate(ctx context.Context) (iter.Seq[ProcessOutput], error) {
+	if err := c.config.Clustering.validate(); err != nil {
+		c.log.Errorf("error validating clustering: %v", err)
+		return nil, err
+	}
+
+	if err := c.config.Merge.validate(c.config.Clustering); err != nil {
+		c.log.Errorf("error validating merge: %v", err)
+		return nil, err
+	}
+
+	if err := validateLinkage(c.config); err != nil {
+		c.log.Errorf("error validating linkage: %v", err)
+		return nil, err
+	}
+
+	if err := validateIndex(c.config); err != nil {
+		c.log.Errorf("error validating match index: %v", err)
+		return nil, err
+	}
+
+	blocker, err := newBlocker(c.config.BlockKeys, c.config.MaxBlockSize)
+	if err != nil {
+		c.log.Errorf("error creating blocker: %v", err)
+		return nil, err
+	}
+
+	accuracyScorer, err := newScorer(c.config)
+	if err != nil {
+		c.log.Errorf("error creating scorer: %v", err)
+		return nil, err
+	}
+
+	if err = validateWorkers(c.config.Workers); err != nil {
+		c.log.Errorf("error validating workers: %v", err)
+		return nil, err
+	}
+
+	contacts, err := c.repository.GetContactData(ctx)
 	if err != nil {
 		c.log.Errorf("error getting contact data: %v", err)
 		return nil, err
 	}
 
-	contactMap := make(map[string]Contact)
-	var results []ProcessOutput
+	// When linking, the reference contacts follow the source ones and only pairs across both datasets are compared
+	var sources int
+	if c.config.Linkage {
+		reference, err := c.repository.GetReferenceData(ctx)
+		if err != nil {
+			c.log.Errorf("error getting reference data: %v", err)
+			return nil, err
+		}
 
-	// Create compared pairs map to validate if already compared
-	comparedPairs := make(map[string]bool)
+		sources = len(contacts)
+		contacts = append(contacts, reference...)
+	}
 
-	var duplicates []Contact
+	rejects, err := c.checkRejects()
+	if err != nil {
+		return nil, err
+	}
+	read := len(contacts)
 
-	for _, contact := range contacts {
-		contactMap[contact.ContactID] = contact
+	// The quality of the input is reported before an incremental run leaves out the unchanged contacts
+	var report []FieldQuality
+	if c.config.Validation.Enabled {
+		report = accuracyScorer.validator.report(contacts)
+		logQualityReport(c.log, report)
 	}
 
-	for i := 0; i < len(contacts); i++ {
-		for j := i + 1; j < len(contacts); j++ {
-			compareContacts(contacts[i], contacts[j], contactMap, comparedPairs, &results, &duplicates)
+	// Generate the candidate pairs before scoring, so only contacts sharing a block are compared.
+	// An incremental run only pairs the new and changed contacts, with the indexed contacts and between them.
+	var indexed indexedContacts
+	var pairs []pair
+	var records []record
+	if c.config.Incremental {
+		indexed, err = c.loadIndexedContacts(ctx, blocker, accuracyScorer, contacts)
+		if err != nil {
+			c.log.Errorf("error loading match index: %v", err)
+			return nil, err
+		}
+
+		contacts, records = indexed.contacts, indexed.records
+		pairs = blocker.pairsBetween(indexed.blockKeys, len(contacts), indexed.base)
+		c.log.Infof("matching %d new or changed contacts against %d indexed contacts, blocking generated %d candidate pairs",
+			len(contacts)-indexed.base, indexed.base, len(pairs))
+	} else {
+		pairs = blocker.candidatePairs(contacts, sources)
+		c.log.Infof("blocking generated %d candidate pairs out of %d (reduction ratio %.4f)",
+			len(pairs), totalPairs(len(contacts), sources), reductionRatio(len(contacts), sources, len(pairs)))
+
+		records = accuracyScorer.prepareAll(contacts)
+		if c.config.Linkage {
+			assignDatasets(records, sources)
 		}
 	}
+	pairs = uniquePairs(records, pairs)
 
-	err = c.repository.WriteDuplicateContacts(duplicates)
+	progress, err := c.loadProgress(ctx, contacts, len(pairs))
 	if err != nil {
-		c.log.Errorf("error writing duplicate contact data: %v", err)
+		c.log.Errorf("error loading checkpoint: %v", err)
 		return nil, err
 	}
 
-	err = c.repository.WriteContactData(results)
+	modelScorer, err := c.newPairScorer(ctx, accuracyScorer, records, pairs, indexed.estimate)
 	if err != nil {
-		c.log.Errorf("error writing contact data: %v", err)
+		c.log.Errorf("error estimating scoring model: %v", err)
 		return nil, err
 	}
 
+	matches, err := scorePairs(ctx, modelScorer, records, pairs, c.config.Workers, progress)
+	if err != nil {
+		c.log.Errorf("error scoring contacts: %v", err)
+		return nil, err
+	}
+
+	// The explanations are computed once, as the outputs are iterated by every writer
+	var explanations [][]FieldExplanation
+	if c.config.Explain {
+		explanations = explainMatches(modelScorer, records, matches)
+	}
+	results, duplicates := matchOutputs(records, matches, explanations), duplicateContacts(records, matches)
+
+	var assignments []ClusterAssignment
+	if c.config.Clustering.Enabled {
+		assignments = clusterContacts(contacts, matches, indexed.clusters, c.config.Clustering)
+	}
+
+	err = c.writeOutputs(ctx, contacts, assignments, results, duplicates, report, rejects)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.config.Index != nil {
+		blockKeys := indexed.blockKeys
+		if blockKeys == nil {
+			blockKeys = blocker.blockKeys(contacts)
+		}
+
+		err = c.config.Index.SaveIndex(ctx, c.buildIndex(records, blockKeys, assignments, modelScorer))
+		if err != nil {
+			c.log.Errorf("error saving match index: %v", err)
+			return nil, err
+		}
+	}
+
+	// The outputs are complete, a later run must not resume from this one
+	if c.config.Checkpoints != nil {
+		err = c.config.Checkpoints.RemoveCheckpoint(ctx)
+		if err != nil {
+			c.log.Errorf("error removing checkpoint: %v", err)
+			return nil, err
+		}
+	}
+
+	found, duplicated := countOutputs(records, matches)
+	c.log.Infof("read %d contacts and rejected %d rows, found %d matches and %d duplicates",
+		read, len(rejects), found, duplicated)
+
 	return results, nil
 }
 
-func compareContacts(contact1, contact2 Contact, contactMap map[string]Contact, comparedPairs map[string]bool, results *[]ProcessOutput, duplicates *[]Contact) {
-	pairKey := generatePairKey(contact1.ContactID, contact2.ContactID)
+// newPairScorer returns the scorer of the configured model, the Fellegi-Sunter model is estimated on the candidate pairs first
+// unless a previous estimate is given
+func (c contactService) newPairScorer(ctx context.Context, s scorer, records []record, pairs []pair, estimate *FellegiSunterEstimate) (pairScorer, error) {
+	if c.config.ScoringModel != FellegiSunterModel {
+		return s, nil
+	}
+
+	if estimate != nil {
+		model, err := restoreFellegiSunterScorer(s, c.config.FellegiSunter, *estimate)
+		if err != nil {
+			return nil, err
+		}
+
+		c.log.Infof("fellegi-sunter model restored from the match index: %s", model.describe())
+		return model, nil
+	}
+
+	model, err := newFellegiSunterScorer(ctx, s, c.config.FellegiSunter, records, pairs, c.config.Workers)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Infof("fellegi-sunter model estimated: %s", model.describe())
+	return model, nil
+}
 
-	if comparedPairs[pairKey] {
-		return
+// loadProgress resumes from the stored checkpoint when resuming, and sets up the periodic checkpoints when a store is configured
+func (c contactService) loadProgress(ctx context.Context, contacts []Contact, pairs int) (scoreProgress, error) {
+	store := c.config.Checkpoints
+	if store == nil {
+		return scoreProgress{}, nil
 	}
 
-	accuracyLevel, duplicate := calculateAccuracy(contact1, contact2)
-	if accuracyLevel > 0 {
-		*results = append(*results, ProcessOutput{
-			ContactIDSource: contact1.ContactID,
-			ContactIDMatch:  contact2.ContactID,
-			AccuracyLevel:   accuracyLevel,
-		})
-		*results = append(*results, ProcessOutput{
-			ContactIDSource: contact2.ContactID,
-			ContactIDMatch:  contact1.ContactID,
-			AccuracyLevel:   accuracyLevel,
-		})
+	inputChecksum, err := store.InputChecksum(ctx)
+	if err != nil {
+		return scoreProgress{}, err
+	}
+	// Without input files, such as when reading from Postgres, the checkpoint is tied to the contacts read and their order
+	if inputChecksum == "" {
+		inputChecksum = contactsChecksum(contacts)
 	}
 
-	if duplicate {
-		*duplicates = append(*duplicates, contact1)
-		*duplicates = append(*duplicates, contact2)
+	configChecksum, err := c.config.scoringChecksum()
+	if err != nil {
+		return scoreProgress{}, err
 	}
 
-	comparedPairs[pairKey] = true
+	interval := c.config.CheckpointInterval
+	if interval <= 0 {
+		interval = defaultCheckpointInterval
+	}
+
+	progress := scoreProgress{
+		interval: interval,
+		save: func(ctx context.Context, scoredPairs, matchCount int, newMatches []match) error {
+			err := store.SaveCheckpoint(ctx, Checkpoint{
+				InputChecksum:  inputChecksum,
+				ConfigChecksum: configChecksum,
+				Pairs:          pairs,
+				ScoredPairs:    scoredPairs,
+				MatchCount:     matchCount,
+				Matches:        toCheckpointMatches(newMatches),
+			})
+			if err != nil {
+				return err
+			}
+
+			c.log.Infof("checkpoint saved after scoring %d of %d candidate pairs", scoredPairs, pairs)
+			return nil
+		},
+	}
+
+	if !c.config.Resume {
+		return progress, nil
+	}
+
+	checkpoint, found, err := store.LoadCheckpoint(ctx)
+	if err != nil {
+		return scoreProgress{}, err
+	}
+	if !found {
+		c.log.Infof("no checkpoint found, scoring from the first candidate pair")
+		return progress, nil
+	}
+
+	if err = checkpoint.validate(inputChecksum, configChecksum, len(contacts), pairs); err != nil {
+		return scoreProgress{}, err
+	}
+
+	c.log.Infof("resuming after %d of %d candidate pairs", checkpoint.ScoredPairs, pairs)
+	progress.scoredPairs = checkpoint.ScoredPairs
+	progress.matches = fromCheckpointMatches(checkpoint.Matches)
+	return progress, nil
 }
 
-// Create unique pair keys
-func generatePairKey(id1, id2 string) string {
-	if id1 < id2 {
-		return id1 + "_" + id2
+// writeOutputs writes every output of the run, in a single transaction when the repository is Transactional
+func (c contactService) writeOutputs(ctx context.Context, contacts []Contact, assignments []ClusterAssignment,
+	results iter.Seq[ProcessOutput], duplicates iter.Seq[Contact], report []FieldQuality, rejects []Reject) error {
+	transactional, ok := c.repository.(Transactional)
+	if !ok {
+		return c.writeResults(ctx, contacts, assignments, results, duplicates, report, rejects)
 	}
-	return id2 + "_" + id1
+
+	err := transactional.Begin(ctx)
+	if err != nil {
+		c.log.Errorf("error beginning transaction: %v", err)
+		return err
+	}
+
+	err = c.writeResults(ctx, contacts, assignments, results, duplicates, report, rejects)
+	if err != nil {
+		// The writing error is the one reported, the rollback logs its own
+		_ = transactional.Rollback(ctx)
+		return err
+	}
+
+	err = transactional.Commit(ctx)
+	if err != nil {
+		c.log.Errorf("error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
 }
 
-func calculateAccuracy(c1, c2 Contact) (int, bool) {
-	var score float64
-	fieldsToCompare := [][2]string{
-		{c1.FirstName, c2.FirstName},
-		{c1.LastName, c2.LastName},
-		{c1.Email, c2.Email},
-		{c1.ZipCode, c2.ZipCode},
-		{c1.Address, c2.Address},
+func (c contactService) writeResults(ctx context.Context, contacts []Contact, assignments []ClusterAssignment,
+	results iter.Seq[ProcessOutput], duplicates iter.Seq[Contact], report []FieldQuality, rejects []Reject) error {
+	if c.config.Clustering.Enabled {
+		err := c.repository.WriteClusters(ctx, slices.Values(assignments))
+		if err != nil {
+			c.log.Errorf("error writing clusters: %v", err)
+			return err
+		}
+
+		if c.config.Merge.Enabled {
+			err = c.writeGoldenRecords(ctx, contacts, assignments)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	for _, fields := range fieldsToCompare {
-		compareAndAddScore(fields[0], fields[1], &score)
+	err := c.repository.WriteDuplicateContacts(ctx, duplicates)
+	if err != nil {
+		c.log.Errorf("error writing duplicate contact data: %v", err)
+		return err
 	}
 
-	if score == 5 {
-		return 0, true
+	err = c.repository.WriteContactData(ctx, results)
+	if err != nil {
+		c.log.Errorf("error writing contact data: %v", err)
+		return err
 	}
 
-	// Since there is no logic defined for the accuracy score, I decided that if there is no match in any of fields, the value of both names matching should be 0,5
-	// so it doesn't generate too much very low accuracy data. Otherwise, it should add 1, since there is some more probably of being the same contact.
-	var firstLetterAddValue float64
-	if score == 0 {
-		firstLetterAddValue = 0.5
-	} else {
-		firstLetterAddValue = 1
+	if c.config.Explain {
+		err = c.repository.WriteExplanations(ctx, results)
+		if err != nil {
+			c.log.Errorf("error writing explanations: %v", err)
+			return err
+		}
 	}
 
-	if c1.FirstName != c2.FirstName {
-		compareFirstLetter(c1.FirstName, c2.FirstName, &score, firstLetterAddValue)
+	if c.config.Validation.Enabled {
+		err = c.repository.WriteQualityReport(ctx, report)
+		if err != nil {
+			c.log.Errorf("error writing data quality report: %v", err)
+			return err
+		}
+	}
+
+	return c.writeRejects(ctx, rejects)
+}
+
+func (c contactService) writeGoldenRecords(ctx context.Context, contacts []Contact, assignments []ClusterAssignment) error {
+	valid, err := newValidator(c.config.Validation)
+	if err != nil {
+		c.log.Errorf("error creating validator: %v", err)
+		return err
+	}
+
+	merged, mapping := mergeClusters(contacts, assignments, c.config.Merge, valid)
+	c.log.Infof("merged %d contacts into %d golden records", len(contacts), len(merged))
+
+	err = c.repository.WriteMergedContacts(ctx, slices.Values(merged))
+	if err != nil {
+		c.log.Errorf("error writing merged contacts: %v", err)
+		return err
 	}
 
-	if c1.LastName != c2.LastName {
-		compareFirstLetter(c1.LastName, c2.LastName, &score, firstLetterAddValue)
+	err = c.repository.WriteContactMapping(ctx, slices.Values(mapping))
+	if err != nil {
+		c.log.Errorf("error writing contact mapping: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// compareContacts scores both contacts, reporting whether they matched or are duplicates
+func compareContacts(s pairScorer, record1, record2 record) (comparison, bool) {
+	result := s.score(record1, record2)
+	return result, result.accuracyLevel > 0 || result.duplicate
+}
+
+// explainMatches breaks the score of every match down by field, in the order of the matches
+func explainMatches(s pairScorer, records []record, matches []match) [][]FieldExplanation {
+	explanations := make([][]FieldExplanation, len(matches))
+	for i, m := range matches {
+		if matchLevel(records, m) > 0 {
+			explanations[i] = s.explain(records[m.i], records[m.j])
+		}
 	}
+	return explanations
+}
+
+// matchOutputs yields every match once per direction, building each output only when it is reached.
+// When explanations isn't nil every match carries the breakdown of its score by field, see explainMatches.
+func matchOutputs(records []record, matches []match, explanations [][]FieldExplanation) iter.Seq[ProcessOutput] {
+	return func(yield func(ProcessOutput) bool) {
+		for i, m := range matches {
+			level := matchLevel(records, m)
+			if level == 0 {
+				continue
+			}
+
+			record1, record2 := records[m.i], records[m.j]
+			var explanation []FieldExplanation
+			if explanations != nil {
+				explanation = explanations[i]
+			}
 
-	return int(score), false
+			output := ProcessOutput{
+				ContactIDSource: record1.ContactID,
+				ContactIDMatch:  record2.ContactID,
+				SourceSide:      record1.dataset,
+				MatchSide:       record2.dataset,
+				AccuracyLevel:   level,
+				PhoneticMatch:   m.phoneticMatch,
+				Explanation:     explanation,
+			}
+			if !yield(output) {
+				return
+			}
+
+			output.ContactIDSource, output.ContactIDMatch = record2.ContactID, record1.ContactID
+			output.SourceSide, output.MatchSide = record2.dataset, record1.dataset
+			if !yield(output) {
+				return
+			}
+		}
+	}
 }
 
-func compareFirstLetter(name1, name2 string, score *float64, addValue float64) {
-	if len(name1) > 0 && len(name2) > 0 {
-		field1 := name1[:1]
-		field2 := name2[:1]
-		if field1 == field2 {
-			*score += addValue
+// duplicateContacts yields both contacts of every duplicate pair
+func duplicateContacts(records []record, matches []match) iter.Seq[Contact] {
+	return func(yield func(Contact) bool) {
+		for _, m := range matches {
+			if !m.duplicate {
+				continue
+			}
+			if !yield(records[m.i].Contact) || !yield(records[m.j].Contact) {
+				return
+			}
 		}
 	}
 }
 
-func compareAndAddScore(field1, field2 string, score *float64) {
-	if len(field1) > 1 && len(field2) > 1 {
-		if field1 == field2 {
-			*score++
+// countOutputs counts the matches and duplicates written, without building them
+func countOutputs(records []record, matches []match) (int, int) {
+	var results, duplicates int
+	for _, m := range matches {
+		if matchLevel(records, m) > 0 {
+			results += 2
+		}
+		if m.duplicate {
+			duplicates += 2
 		}
 	}
+	return results, duplicates
+}
+
+// matchLevel is the accuracy level written for the match.
+// When linking, duplicates across both datasets are also matches of the highest accuracy.
+func matchLevel(records []record, m match) int {
+	if m.duplicate && records[m.i].dataset != records[m.j].dataset {
+		return 5
+	}
+	return m.accuracyLevel
+}
+
+// Create unique pair keys
+func generatePairKey(id1, id2 string) string {
+	if id1 < id2 {
+		return id1 + "_" + id2
+	}
+	return id2 + "_" + id1
 }
@@ -1,9 +1,13 @@
 package contact_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/sebastianreh/compass-code-assessment/internal/contact"
-	"github.com/sebastianreh/mocks"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"iter"
+	"slices"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -11,6 +15,34 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// collect iterates the matches returned by Evaluate
+func collect(results iter.Seq[contact.ProcessOutput], err error) ([]contact.ProcessOutput, error) {
+	if err != nil {
+		return nil, err
+	}
+	return slices.Collect(results), nil
+}
+
+// generatedContacts returns n contacts whose names, emails, zip codes and addresses repeat with different periods,
+// so they share blocks and match at every accuracy level
+func generatedContacts(n int) []contact.Contact {
+	firstNames := []string{"John", "Jon", "Jane", "Janet", "Robert", "Bob"}
+	lastNames := []string{"Doe", "Dough", "Smith", "Smyth"}
+
+	contacts := make([]contact.Contact, n)
+	for i := range contacts {
+		contacts[i] = contact.Contact{
+			ContactID: fmt.Sprint(i + 1),
+			FirstName: firstNames[i%len(firstNames)],
+			LastName:  lastNames[i%len(lastNames)],
+			Email:     fmt.Sprintf("user%d@example.com", i%7),
+			ZipCode:   fmt.Sprint(10000 + i%5),
+			Address:   fmt.Sprintf("%d Main St", i%9),
+		}
+	}
+	return contacts
+}
+
 func TestContactService_Evaluate(t *testing.T) {
 	logger := logrus.New()
 
@@ -21,12 +53,12 @@ func TestContactService_Evaluate(t *testing.T) {
 			{ContactID: "2", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
 		}
 
-		mockRepo.On("GetContactData").Return(mockContacts, nil)
-		mockRepo.On("WriteContactData", mock.Anything).Return(nil)
-		mockRepo.On("WriteDuplicateContacts", mock.Anything).Return(nil)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
 
-		service := contact.NewContactService(logger, mockRepo)
-		results, err := service.Evaluate()
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		results, err := collect(service.Evaluate(context.Background()))
 
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(results))
@@ -38,10 +70,10 @@ func TestContactService_Evaluate(t *testing.T) {
 
 	t.Run("when repository returns error while getting contacts, it should return an error", func(t *testing.T) {
 		mockRepo := new(mocks.RepositoryMock)
-		mockRepo.On("GetContactData").Return([]contact.Contact{}, errors.New("error fetching contacts"))
+		mockRepo.On("GetContactData", mock.Anything).Return([]contact.Contact{}, errors.New("error fetching contacts"))
 
-		service := contact.NewContactService(logger, mockRepo)
-		_, err := service.Evaluate()
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		_, err := collect(service.Evaluate(context.Background()))
 
 		assert.NotNil(t, err)
 		assert.Equal(t, "error fetching contacts", err.Error())
@@ -56,12 +88,12 @@ func TestContactService_Evaluate(t *testing.T) {
 			{ContactID: "2", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
 		}
 
-		mockRepo.On("GetContactData").Return(mockContacts, nil)
-		mockRepo.On("WriteContactData", mock.Anything).Return(errors.New("failed to write contact data"))
-		mockRepo.On("WriteDuplicateContacts", mock.Anything).Return(nil)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(errors.New("failed to write contact data"))
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
 
-		service := contact.NewContactService(logger, mockRepo)
-		_, err := service.Evaluate()
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		_, err := collect(service.Evaluate(context.Background()))
 
 		assert.NotNil(t, err)
 		assert.Equal(t, "failed to write contact data", err.Error())
@@ -77,15 +109,761 @@ func TestContactService_Evaluate(t *testing.T) {
 			{ContactID: "3", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
 		}
 
-		mockRepo.On("GetContactData").Return(mockContacts, nil)
-		mockRepo.On("WriteDuplicateContacts", mock.Anything).Return(errors.New("failed to write duplicate contacts"))
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(errors.New("failed to write duplicate contacts"))
 
-		service := contact.NewContactService(logger, mockRepo)
-		_, err := service.Evaluate()
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		_, err := collect(service.Evaluate(context.Background()))
 
 		assert.NotNil(t, err)
 		assert.Equal(t, "failed to write duplicate contacts", err.Error())
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("when block keys are configured, it should only compare contacts sharing a block", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "Jane", LastName: "Roe", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
+			{ContactID: "3", FirstName: "Jim", LastName: "Poe", Email: "jim@example.org", ZipCode: "12345", Address: "789 Pine St"},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{BlockKeys: []string{contact.ZipBlockKey}})
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, "1", results[0].ContactIDSource)
+		assert.Equal(t, "3", results[0].ContactIDMatch)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when multiple block keys are configured, it should union their candidate pairs", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
+			{ContactID: "3", FirstName: "Jim", LastName: "Poe", Email: "jim@example.org", ZipCode: "12345", Address: "789 Pine St"},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{BlockKeys: []string{contact.ZipBlockKey, contact.EmailDomainBlockKey}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(results))
+		assert.Equal(t, "1", results[0].ContactIDSource)
+		assert.Equal(t, "2", results[0].ContactIDMatch)
+		assert.Equal(t, "1", results[2].ContactIDSource)
+		assert.Equal(t, "3", results[2].ContactIDMatch)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when an unknown block key is configured, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{BlockKeys: []string{"unknown"}})
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.UnknownBlockKeyError)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when comparators are configured, it should award partial credit to similar fields", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "Jon", LastName: "Doe", Email: "jonh.doe@example.com", ZipCode: "54321", Address: "123 Main St."},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{Comparators: map[contact.Field]contact.FieldComparator{
+			contact.FirstNameField: {Comparator: contact.JaroWinklerComparator, Threshold: 0.9},
+			contact.EmailField:     {Comparator: contact.DamerauLevenshteinComparator, Threshold: 0.9},
+			contact.AddressField:   {Comparator: contact.LevenshteinComparator, Threshold: 0.85},
+		}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, 3, results[0].AccuracyLevel)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when a comparator is not known, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		config := contact.Config{Comparators: map[contact.Field]contact.FieldComparator{
+			contact.EmailField: {Comparator: "unknown", Threshold: 0.9},
+		}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.UnknownComparatorError)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when phonetic encoders are configured, it should credit names that sound alike and expose the encoder", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "Catherine", LastName: "Schmidt", Email: "cs@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "Kathryn", LastName: "Smith", Email: "cs@example.com", ZipCode: "54321", Address: "456 Oak St"},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{PhoneticEncoders: []contact.PhoneticEncoder{contact.MetaphoneEncoder, contact.DoubleMetaphoneEncoder}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, 3, results[0].AccuracyLevel)
+		assert.Equal(t, "first_name:metaphone;last_name:double_metaphone", results[0].PhoneticMatch)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when phonetic encoders are configured, it should not credit names only sharing the first letter", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Smith", Email: "js@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "John", LastName: "Sanchez", Email: "js@example.com", ZipCode: "54321", Address: "456 Oak St"},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{PhoneticEncoders: []contact.PhoneticEncoder{contact.DoubleMetaphoneEncoder}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, 2, results[0].AccuracyLevel)
+		assert.Equal(t, "", results[0].PhoneticMatch)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the similarity credit beats the phonetic credit, it should not expose the encoder", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "Johnathan", LastName: "Smith", Email: "js@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "Jonathan", LastName: "Baker", Email: "jb@example.org", ZipCode: "54321", Address: "456 Oak St"},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteExplanations", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{
+			Comparators:      map[contact.Field]contact.FieldComparator{contact.FirstNameField: {Comparator: contact.JaroWinklerComparator, Threshold: 0.9}},
+			PhoneticEncoders: []contact.PhoneticEncoder{contact.SoundexEncoder},
+			AccuracyCutoffs:  contact.AccuracyCutoffs{0.5, 2, 3, 4, 5},
+			Explain:          true,
+		}
+		service := contact.NewContactService(logger, mockRepo, config)
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, 1, results[0].AccuracyLevel)
+		assert.Equal(t, "", results[0].PhoneticMatch)
+		assert.Equal(t, contact.SimilarFieldMatch, results[0].Explanation[0].Match)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when a nickname table is configured, it should credit nicknames as a first name match", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "Robert", LastName: "Doe", Email: "robert@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "Bob", LastName: "Doe", Email: "bob@example.com", ZipCode: "12345", Address: "456 Oak St"},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{Nicknames: contact.NewNicknameTable()})
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, 3, results[0].AccuracyLevel)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when email normalization is enabled, it should match canonical emails and keep the original values", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "John.Doe+crm@Gmail.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "John", LastName: "Doe", Email: "johndoe@gmail.com", ZipCode: "12345", Address: "123 Main St"},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mockContacts).Return(nil)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{NormalizeEmails: true})
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(results))
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when address parsing is enabled, it should match addresses that only differ in abbreviations", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockContacts := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "7641 Justo Avenue"},
+			{ContactID: "2", FirstName: "Mary", LastName: "Roe", Email: "mary@example.com", ZipCode: "54321", Address: "7641 Justo Ave."},
+		}
+
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{ParseAddresses: true})
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, 1, results[0].AccuracyLevel)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestContactService_EvaluateClustering(t *testing.T) {
+	logger := logrus.New()
+	mockContacts := []contact.Contact{
+		{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "a@example.com", ZipCode: "11111", Address: "1 Main St"},
+		{ContactID: "2", FirstName: "John", LastName: "Doe", Email: "a@example.com", ZipCode: "11111", Address: "2 Oak St"},
+		{ContactID: "3", FirstName: "John", LastName: "Doe", Email: "c@example.com", ZipCode: "11111", Address: "2 Oak St"},
+		{ContactID: "4", FirstName: "Mary", LastName: "Roe", Email: "m@example.org", ZipCode: "99999", Address: "9 Elm St"},
+	}
+
+	t.Run("when clustering by connected components, it should group contacts linked by a chain of matches", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteClusters", mock.Anything, []contact.ClusterAssignment{
+			{ContactID: "1", ClusterID: 1},
+			{ContactID: "2", ClusterID: 1},
+			{ContactID: "3", ClusterID: 1},
+			{ContactID: "4", ClusterID: 2},
+		}).Return(nil)
+
+		config := contact.Config{Clustering: contact.ClusteringConfig{
+			Enabled: true, Method: contact.ConnectedComponentsClustering, MinAccuracy: 4,
+		}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when using correlation clustering, it should not follow chains of matches", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteClusters", mock.Anything, []contact.ClusterAssignment{
+			{ContactID: "1", ClusterID: 1},
+			{ContactID: "2", ClusterID: 1},
+			{ContactID: "3", ClusterID: 2},
+			{ContactID: "4", ClusterID: 3},
+		}).Return(nil)
+
+		config := contact.Config{Clustering: contact.ClusteringConfig{
+			Enabled: true, Method: contact.CorrelationClustering, MinAccuracy: 4,
+		}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the clustering method is unknown, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		config := contact.Config{Clustering: contact.ClusteringConfig{Enabled: true, Method: "unknown"}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.UnknownClusteringMethodError)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when writing clusters fails, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteClusters", mock.Anything, mock.Anything).Return(errors.New("failed to write clusters"))
+
+		config := contact.Config{Clustering: contact.ClusteringConfig{
+			Enabled: true, Method: contact.ConnectedComponentsClustering, MinAccuracy: 4,
+		}}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to write clusters", err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestContactService_EvaluateMerge(t *testing.T) {
+	logger := logrus.New()
+	mockContacts := []contact.Contact{
+		{ContactID: "1", FirstName: "Bob", LastName: "Doe", Email: "bob@example.com", ZipCode: "11111", Address: "1 Main St", Source: "leads", UpdatedAt: "2023-05-01"},
+		{ContactID: "2", FirstName: "Robert", LastName: "Doe", Email: "robert@example.com", ZipCode: "11111", Address: "1 Main St", Source: "crm", UpdatedAt: "2024-02-01"},
+		{ContactID: "3", FirstName: "Mary", LastName: "Roe", Email: "mary@example.org", ZipCode: "99999", Address: "9 Elm St"},
+	}
+	clustering := contact.ClusteringConfig{Enabled: true, Method: contact.ConnectedComponentsClustering, MinAccuracy: 3}
+
+	t.Run("when merging clusters, it should write a golden record per cluster and the surviving IDs", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteClusters", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteMergedContacts", mock.Anything, []contact.Contact{
+			{ContactID: "1", FirstName: "Robert", LastName: "Doe", Email: "robert@example.com", ZipCode: "11111", Address: "1 Main St", Source: "leads", UpdatedAt: "2024-02-01"},
+			{ContactID: "3", FirstName: "Mary", LastName: "Roe", Email: "mary@example.org", ZipCode: "99999", Address: "9 Elm St"},
+		}).Return(nil)
+		mockRepo.On("WriteContactMapping", mock.Anything, []contact.ContactMapping{
+			{ContactID: "1", SurvivingContactID: "1"},
+			{ContactID: "2", SurvivingContactID: "1"},
+			{ContactID: "3", SurvivingContactID: "3"},
+		}).Return(nil)
+
+		config := contact.Config{
+			Clustering: clustering,
+			Merge: contact.MergeConfig{
+				Enabled: true,
+				Rules: map[contact.Field]contact.SurvivorshipRule{
+					contact.FirstNameField: contact.LongestRule,
+					contact.EmailField:     contact.MostRecentRule,
+				},
+			},
+		}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the preferred source rule is used, it should keep the value of the most trusted source", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteClusters", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteMergedContacts", mock.Anything, mock.MatchedBy(func(merged []contact.Contact) bool {
+			return len(merged) == 2 && merged[0].FirstName == "Bob" && merged[0].Email == "bob@example.com"
+		})).Return(nil)
+		mockRepo.On("WriteContactMapping", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{
+			Clustering: clustering,
+			Merge: contact.MergeConfig{
+				Enabled: true,
+				Rules: map[contact.Field]contact.SurvivorshipRule{
+					contact.FirstNameField: contact.PreferredSourceRule,
+					contact.EmailField:     contact.PreferredSourceRule,
+				},
+				PreferredSources: []string{"leads", "crm"},
+			},
+		}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when validation is enabled, it should not keep placeholder nor invalid values in the golden record", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		contacts := slices.Clone(mockContacts)
+		contacts[1].FirstName = "R0bert"
+		contacts[1].Email = "unknown@example.com"
+		mockRepo.On("GetContactData", mock.Anything).Return(contacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteClusters", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteMergedContacts", mock.Anything, mock.MatchedBy(func(merged []contact.Contact) bool {
+			return len(merged) == 2 && merged[0].FirstName == "Bob" && merged[0].Email == "bob@example.com"
+		})).Return(nil)
+		mockRepo.On("WriteContactMapping", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{
+			Clustering: clustering,
+			Validation: contact.ValidationConfig{Enabled: true},
+			Merge: contact.MergeConfig{
+				Enabled: true,
+				Rules: map[contact.Field]contact.SurvivorshipRule{
+					contact.FirstNameField: contact.LongestRule,
+					contact.EmailField:     contact.MostRecentRule,
+				},
+			},
+		}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when merging without clustering, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{Merge: contact.MergeConfig{Enabled: true}})
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.MergeWithoutClusteringError, err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when a survivorship rule is unknown, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		config := contact.Config{
+			Clustering: clustering,
+			Merge: contact.MergeConfig{
+				Enabled: true,
+				Rules:   map[contact.Field]contact.SurvivorshipRule{contact.EmailField: "unknown"},
+			},
+		}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.UnknownSurvivorshipRuleError)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when a rule is set for a field that is not merged, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		config := contact.Config{
+			Clustering: clustering,
+			Merge: contact.MergeConfig{
+				Enabled: true,
+				Rules:   map[contact.Field]contact.SurvivorshipRule{"emial": contact.LongestRule},
+			},
+		}
+		service := contact.NewContactService(logger, mockRepo, config)
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.UnknownMergeFieldError+`: "emial"`, err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestContactService_EvaluateWeights(t *testing.T) {
+	logger := logrus.New()
+	// Both pairs match on a single field: 1 and 2 share the email, 3 and 4 share the zip code
+	mockContacts := []contact.Contact{
+		{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+		{ContactID: "2", FirstName: "Mary", LastName: "Poe", Email: "john@example.com", ZipCode: "54321", Address: "456 Oak St"},
+		{ContactID: "3", FirstName: "Anna", LastName: "Roe", Email: "anna@example.com", ZipCode: "99999", Address: "789 Elm St"},
+		{ContactID: "4", FirstName: "Luke", LastName: "Moe", Email: "luke@example.com", ZipCode: "99999", Address: "321 Pine St"},
+	}
+
+	t.Run("when field weights are configured, it should score each field by its weight", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{Weights: map[contact.Field]float64{contact.EmailField: 3, contact.ZipCodeField: 0.5}}
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, "1", results[0].ContactIDSource)
+		assert.Equal(t, "2", results[0].ContactIDMatch)
+		assert.Equal(t, 3, results[0].AccuracyLevel)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when accuracy cutoffs are configured, it should map the score to the level of the highest reached cutoff", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{
+			Weights:         map[contact.Field]float64{contact.EmailField: 3},
+			AccuracyCutoffs: contact.AccuracyCutoffs{0.5, 1, 1.5, 2, 2.5},
+		}
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(results))
+		assert.Equal(t, "1", results[0].ContactIDSource)
+		assert.Equal(t, 5, results[0].AccuracyLevel)
+		assert.Equal(t, "3", results[2].ContactIDSource)
+		assert.Equal(t, 2, results[2].AccuracyLevel)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when a weight is negative, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		config := contact.Config{Weights: map[contact.Field]float64{contact.EmailField: -1}}
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidWeightError)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when the accuracy cutoffs are not ascending, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		config := contact.Config{AccuracyCutoffs: contact.AccuracyCutoffs{1, 3, 2, 4, 5}}
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidCutoffsError)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestContactService_EvaluateExplain(t *testing.T) {
+	logger := logrus.New()
+	mockContacts := []contact.Contact{
+		{ContactID: "1", FirstName: "Robert", LastName: "Smith", Email: "rob.smith@example.com", ZipCode: "12345", Address: "123 Main St"},
+		{ContactID: "85", FirstName: "Bob", LastName: "Smyth", Email: "rob.smith@example.com", ZipCode: "", Address: "456 Oak St"},
+	}
+
+	t.Run("when explanations are enabled, it should break the score of every match down by field and write them", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteExplanations", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{
+			PhoneticEncoders: []contact.PhoneticEncoder{contact.SoundexEncoder},
+			Nicknames:        contact.NewNicknameTable(),
+			Weights:          map[contact.Field]float64{contact.EmailField: 2},
+			Explain:          true,
+		}
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, 4, results[0].AccuracyLevel)
+		expected := []contact.FieldExplanation{
+			{Field: contact.FirstNameField, Match: contact.NicknameFieldMatch, Score: 1},
+			{Field: contact.LastNameField, Match: contact.PhoneticFieldMatch, Method: string(contact.SoundexEncoder), Score: 1},
+			{Field: contact.EmailField, Match: contact.ExactFieldMatch, Score: 2},
+			{Field: contact.ZipCodeField, Match: contact.MissingFieldMatch},
+			{Field: contact.AddressField, Match: contact.NoFieldMatch},
+		}
+		assert.Equal(t, expected, results[0].Explanation)
+		assert.Equal(t, expected, results[1].Explanation)
+
+		mockRepo.AssertCalled(t, "WriteExplanations", mock.Anything, results)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when explanations are disabled, it should not explain nor write them", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		results, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{}).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(results))
+		assert.Nil(t, results[0].Explanation)
+
+		mockRepo.AssertNotCalled(t, "WriteExplanations", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when writing explanations fails, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteExplanations", mock.Anything, mock.Anything).Return(errors.New("failed to write CSV"))
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{Explain: true}).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestContactService_EvaluateTransactional(t *testing.T) {
+	logger := logrus.New()
+	mockContacts := []contact.Contact{
+		{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+		{ContactID: "2", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
+	}
+
+	t.Run("when the repository is transactional, it should commit once every output is written", func(t *testing.T) {
+		mockRepo := new(mocks.TransactionalRepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("Begin", mock.Anything).Return(nil).Once()
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("Commit", mock.Anything).Return(nil).Once()
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Len(t, results, 2)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Rollback", mock.Anything)
+	})
+
+	t.Run("when writing an output fails, it should roll back and return the writing error", func(t *testing.T) {
+		mockRepo := new(mocks.TransactionalRepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("Begin", mock.Anything).Return(nil).Once()
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(errors.New("failed to write contact data"))
+		mockRepo.On("Rollback", mock.Anything).Return(nil).Once()
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to write contact data", err.Error())
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("when the transaction can't begin, it should not write any output", func(t *testing.T) {
+		mockRepo := new(mocks.TransactionalRepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("Begin", mock.Anything).Return(errors.New("connection refused"))
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "WriteContactData", mock.Anything, mock.Anything)
+	})
+}
+
+func TestContactService_EvaluateRejects(t *testing.T) {
+	logger := logrus.New()
+	mockContacts := []contact.Contact{
+		{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+		{ContactID: "2", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", ZipCode: "54321", Address: "456 Oak St"},
+	}
+	rejects := []contact.Reject{
+		{Line: 2, Reason: "record has 2 fields, the header has 6", Record: []string{"3", "Jim"}},
+		{Line: 4, Reason: "record has 7 fields, the header has 6", Record: []string{"5", "Ann", "Lee", "", "", "", ""}},
+	}
+
+	t.Run("when rows were rejected within the limit, it should write them and evaluate the contacts", func(t *testing.T) {
+		mockRepo := new(mocks.RejectingRepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("Rejects").Return(rejects)
+		mockRepo.On("WriteRejects", mock.Anything, rejects).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{MaxRejects: 2})
+		results, err := collect(service.Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Len(t, results, 2)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when more rows are rejected than allowed, it should return an error without writing any output", func(t *testing.T) {
+		mockRepo := new(mocks.RejectingRepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("Rejects").Return(rejects)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{MaxRejects: 1})
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.TooManyRejectsError+": 2 rows rejected, at most 1 allowed", err.Error())
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "WriteRejects", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "WriteContactData", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when the run is cancelled, it should keep the rejected rows of the previous run", func(t *testing.T) {
+		mockRepo := new(mocks.RejectingRepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("Rejects").Return(rejects)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{}).Evaluate(ctx))
+
+		assert.ErrorIs(t, err, context.Canceled)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "WriteRejects", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when writing the rejected rows fails, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RejectingRepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("Rejects").Return(rejects)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteRejects", mock.Anything, rejects).Return(errors.New("failed to write rejects"))
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{})
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "failed to write rejects", err.Error())
+		mockRepo.AssertExpectations(t)
+	})
 }
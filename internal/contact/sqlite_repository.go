@@ -0,0 +1,277 @@
+package contact
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type sqliteRepository struct {
+	log     *logrus.Logger
+	db      *sql.DB
+	config  DatabaseConfig
+	columns ColumnMapping
+	// tx is the transaction of the run between Begin and Commit or Rollback, every output is written in it
+	tx *sql.Tx
+}
+
+// NewSQLiteRepository reads the contacts from the configured table or query, its columns are found by name with the
+// given mapping like a CSV header, a nil mapping uses DefaultColumnMapping. Every output is written to its own table,
+// in the transaction of the run when one was begun, see Transactional.
+func NewSQLiteRepository(log *logrus.Logger, db *sql.DB, config DatabaseConfig, columns ColumnMapping) Repository {
+	if columns == nil {
+		columns = DefaultColumnMapping()
+	}
+
+	return &sqliteRepository{
+		log:     log,
+		db:      db,
+		config:  config,
+		columns: columns,
+	}
+}
+
+func (s *sqliteRepository) GetContactData(ctx context.Context) ([]Contact, error) {
+	return s.readContacts(ctx, s.config.ContactsTable, s.config.ContactsQuery)
+}
+
+func (s *sqliteRepository) GetReferenceData(ctx context.Context) ([]Contact, error) {
+	return s.readContacts(ctx, s.config.ReferenceTable, s.config.ReferenceQuery)
+}
+
+func (s *sqliteRepository) readContacts(ctx context.Context, table, query string) ([]Contact, error) {
+	query, err := sourceQuery(table, query)
+	if err != nil {
+		s.log.Errorf("Error reading contacts: %v", err)
+		return nil, err
+	}
+
+	contacts, err := parseContacts(s.queryRows(ctx, query), s.columns, skipNoRows)
+	if err != nil {
+		s.log.Errorf("Error reading contacts: %v", err)
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// queryRows yields the column names of the query and then the values of every row, the same way as StreamCSV
+func (s *sqliteRepository) queryRows(ctx context.Context, query string) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		rows, err := s.db.QueryContext(ctx, query)
+		if err != nil {
+			yield(nil, fmt.Errorf("error querying contacts: %w", err))
+			return
+		}
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		if err != nil {
+			yield(nil, fmt.Errorf("error querying contacts: %w", err))
+			return
+		}
+		if !yield(columns, nil) {
+			return
+		}
+
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		for rows.Next() {
+			if err = rows.Scan(pointers...); err != nil {
+				yield(nil, fmt.Errorf("error reading row: %w", err))
+				return
+			}
+
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = sqlText(value)
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+
+		if err = rows.Err(); err != nil {
+			yield(nil, fmt.Errorf("error reading row: %w", err))
+		}
+	}
+}
+
+// sqlText formats a column value as text, NULL is empty
+func sqlText(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case time.Time:
+		return value.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// writeRows replaces the table with a row per value in a transaction, so the previous rows are kept when writing fails
+func writeRows[T any](ctx context.Context, s *sqliteRepository, table outputTable[T], values iter.Seq[T]) error {
+	table = table.prefixed(s.config.OutputPrefix)
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, table.drop()); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, table.create()); err != nil {
+			return err
+		}
+
+		insert, err := tx.PrepareContext(ctx, insertRow(table))
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+
+		for value := range values {
+			if _, err = insert.ExecContext(ctx, table.row(value)...); err != nil {
+				return err
+			}
+		}
+
+		// Indexing once every row is inserted is faster than updating the indices on each insert
+		for _, index := range table.createIndexes() {
+			if _, err = tx.ExecContext(ctx, index); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Errorf("Error writing to table %s: %v", table.name, err)
+		return err
+	}
+
+	return nil
+}
+
+// Begin starts the transaction every output of the run is written in
+func (s *sqliteRepository) Begin(ctx context.Context) error {
+	if s.tx != nil {
+		return errors.New(TransactionInProgressError)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.log.Errorf("Error beginning transaction: %v", err)
+		return err
+	}
+
+	s.tx = tx
+	return nil
+}
+
+// Commit makes every output of the run visible at once
+func (s *sqliteRepository) Commit(context.Context) error {
+	if s.tx == nil {
+		return nil
+	}
+
+	err := s.tx.Commit()
+	s.tx = nil
+	if err != nil {
+		s.log.Errorf("Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Rollback discards every output of the run, the tables keep the outputs of the previous run
+func (s *sqliteRepository) Rollback(context.Context) error {
+	if s.tx == nil {
+		return nil
+	}
+
+	err := s.tx.Rollback()
+	s.tx = nil
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
+		s.log.Errorf("Error rolling back transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// inTransaction writes in the transaction of the run, or in a transaction of its own when no run began one
+func (s *sqliteRepository) inTransaction(ctx context.Context, write func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return write(s.tx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = write(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteRepository) WriteContactData(ctx context.Context, data iter.Seq[ProcessOutput]) error {
+	return writeRows(ctx, s, matchesTable, data)
+}
+
+func (s *sqliteRepository) WriteDuplicateContacts(ctx context.Context, data iter.Seq[Contact]) error {
+	return writeRows(ctx, s, duplicatesTable, data)
+}
+
+func (s *sqliteRepository) WriteClusters(ctx context.Context, assignments iter.Seq[ClusterAssignment]) error {
+	return writeRows(ctx, s, clustersTable, assignments)
+}
+
+func (s *sqliteRepository) WriteMergedContacts(ctx context.Context, contacts iter.Seq[Contact]) error {
+	return writeRows(ctx, s, mergedTable, contacts)
+}
+
+func (s *sqliteRepository) WriteContactMapping(ctx context.Context, mapping iter.Seq[ContactMapping]) error {
+	return writeRows(ctx, s, mappingTable, mapping)
+}
+
+// WriteExplanations writes a row per field of every match
+func (s *sqliteRepository) WriteExplanations(ctx context.Context, data iter.Seq[ProcessOutput]) error {
+	return writeRows(ctx, s, explanationsTable, explanationRows(data))
+}
+
+func (s *sqliteRepository) WriteQualityReport(ctx context.Context, report []FieldQuality) error {
+	return writeRows(ctx, s, qualityTable, slices.Values(report))
+}
+
+// insertRow is the statement inserting a row in the table
+func insertRow[T any](table outputTable[T]) string {
+	columns := make([]string, len(table.columns))
+	for i, column := range table.columns {
+		columns[i] = quoteIdentifier(column.name)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(table.columns)), ", ")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(table.name), strings.Join(columns, ", "), placeholders)
+}
@@ -0,0 +1,206 @@
+package contact_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteRepository(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	// openDB creates a database with a contacts table holding the original input header
+	openDB := func(t *testing.T) *sql.DB {
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "contacts.db"))
+		assert.Nil(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		_, err = db.Exec(`CREATE TABLE contacts (contactID INTEGER, name TEXT, name1 TEXT, email TEXT, postalZip INTEGER, address TEXT);
+			INSERT INTO contacts VALUES (1, 'John', 'Doe', 'john@example.com', 12345, '123 Main St'),
+				(2, 'Jane', 'Smith', NULL, 54321, '456 Oak St')`)
+		assert.Nil(t, err)
+		return db
+	}
+
+	t.Run("when reading the contacts table, it should find the columns by name", func(t *testing.T) {
+		repo := contact.NewSQLiteRepository(logger, openDB(t), contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+
+		contacts, err := repo.GetContactData(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "Jane", LastName: "Smith", ZipCode: "54321", Address: "456 Oak St"},
+		}, contacts)
+	})
+
+	t.Run("when a query is given, it should read the contacts it selects", func(t *testing.T) {
+		config := contact.DatabaseConfig{
+			ContactsTable:  "contacts",
+			ReferenceQuery: "SELECT contactID AS contact_id, name, name1, email, postalZip, address, 'crm' AS source FROM contacts WHERE contactID = 2",
+		}
+		repo := contact.NewSQLiteRepository(logger, openDB(t), config, nil)
+
+		contacts, err := repo.GetReferenceData(ctx)
+
+		assert.Nil(t, err)
+		assert.Len(t, contacts, 1)
+		assert.Equal(t, "2", contacts[0].ContactID)
+		assert.Equal(t, "crm", contacts[0].Source)
+	})
+
+	t.Run("when there is no table nor query, it should return an error", func(t *testing.T) {
+		repo := contact.NewSQLiteRepository(logger, openDB(t), contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+
+		_, err := repo.GetReferenceData(ctx)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.DatabaseSourceError, err.Error())
+	})
+
+	t.Run("when the table misses a column, it should return an error", func(t *testing.T) {
+		config := contact.DatabaseConfig{ContactsQuery: "SELECT contactID, name FROM contacts"}
+		repo := contact.NewSQLiteRepository(logger, openDB(t), config, nil)
+
+		_, err := repo.GetContactData(ctx)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.MissingColumnError)
+	})
+
+	t.Run("when writing the outputs, it should replace their tables and index them", func(t *testing.T) {
+		db := openDB(t)
+		repo := contact.NewSQLiteRepository(logger, db, contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+		outputs := []contact.ProcessOutput{
+			{ContactIDSource: "1", ContactIDMatch: "2", AccuracyLevel: 4, PhoneticMatch: "last_name:soundex"},
+			{ContactIDSource: "2", ContactIDMatch: "1", AccuracyLevel: 4, PhoneticMatch: "last_name:soundex"},
+		}
+
+		assert.Nil(t, repo.WriteContactData(ctx, slices.Values(outputs[:1])))
+		assert.Nil(t, repo.WriteContactData(ctx, slices.Values(outputs)))
+		assert.Nil(t, repo.WriteClusters(ctx, slices.Values([]contact.ClusterAssignment{{ContactID: "1", ClusterID: 1}, {ContactID: "2", ClusterID: 1}})))
+
+		var matches, level int
+		var accuracy string
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*), MAX(accuracy_level), MAX(accuracy) FROM matches").Scan(&matches, &level, &accuracy))
+		assert.Equal(t, 2, matches)
+		assert.Equal(t, 4, level)
+		assert.Equal(t, "High", accuracy)
+
+		var clusterID int
+		assert.Nil(t, db.QueryRow("SELECT cluster_id FROM clusters WHERE contact_id = '2'").Scan(&clusterID))
+		assert.Equal(t, 1, clusterID)
+
+		var indexes int
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'matches'").Scan(&indexes))
+		assert.Equal(t, 2, indexes)
+	})
+
+	t.Run("when writing the explanations, it should write a row per field of every match", func(t *testing.T) {
+		db := openDB(t)
+		repo := contact.NewSQLiteRepository(logger, db, contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+		outputs := []contact.ProcessOutput{
+			{ContactIDSource: "1", ContactIDMatch: "2", AccuracyLevel: 2, Explanation: []contact.FieldExplanation{
+				{Field: contact.FirstNameField, Match: contact.PhoneticFieldMatch, Method: "double_metaphone", Score: 1},
+				{Field: contact.EmailField, Match: contact.SimilarFieldMatch, Method: "damerau_levenshtein", Score: 0.9333},
+			}},
+		}
+
+		assert.Nil(t, repo.WriteExplanations(ctx, slices.Values(outputs)))
+
+		var rows int
+		var score float64
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*), SUM(score) FROM explanations WHERE contact_id_source = '1'").Scan(&rows, &score))
+		assert.Equal(t, 2, rows)
+		assert.InDelta(t, 1.9333, score, 1e-9)
+	})
+
+	t.Run("when writing the data quality report, it should write a row per field", func(t *testing.T) {
+		db := openDB(t)
+		repo := contact.NewSQLiteRepository(logger, db, contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+		report := []contact.FieldQuality{
+			{Field: contact.EmailField, Values: 2, Valid: 1, Missing: 1},
+			{Field: contact.ZipCodeField, Values: 2, Valid: 1, Placeholder: 1},
+		}
+
+		assert.Nil(t, repo.WriteQualityReport(ctx, report))
+
+		var placeholder int
+		assert.Nil(t, db.QueryRow("SELECT placeholder FROM data_quality WHERE field = 'zip_code'").Scan(&placeholder))
+		assert.Equal(t, 1, placeholder)
+	})
+
+	t.Run("when the context is cancelled while writing, it should keep the previous rows", func(t *testing.T) {
+		db := openDB(t)
+		repo := contact.NewSQLiteRepository(logger, db, contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+		assert.Nil(t, repo.WriteContactMapping(ctx, slices.Values([]contact.ContactMapping{{ContactID: "1", SurvivingContactID: "1"}})))
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		err := repo.WriteContactMapping(cancelled, slices.Values([]contact.ContactMapping{{ContactID: "2", SurvivingContactID: "1"}}))
+
+		var contactID string
+		assert.NotNil(t, err)
+		assert.Nil(t, db.QueryRow("SELECT contact_id FROM contact_mapping").Scan(&contactID))
+		assert.Equal(t, "1", contactID)
+	})
+
+	t.Run("when an output prefix is set, it should write the outputs to the prefixed tables", func(t *testing.T) {
+		db := openDB(t)
+		repo := contact.NewSQLiteRepository(logger, db, contact.DatabaseConfig{ContactsTable: "contacts", OutputPrefix: "dedup_"}, nil)
+
+		assert.Nil(t, repo.WriteClusters(ctx, slices.Values([]contact.ClusterAssignment{{ContactID: "1", ClusterID: 1}})))
+
+		var clusters, indexes int
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*) FROM dedup_clusters").Scan(&clusters))
+		assert.Equal(t, 1, clusters)
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'dedup_clusters'").Scan(&indexes))
+		assert.Equal(t, 2, indexes)
+	})
+
+	t.Run("when the run is rolled back, it should keep every table of the previous run", func(t *testing.T) {
+		db := openDB(t)
+		repo := contact.NewSQLiteRepository(logger, db, contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+		transactional, ok := repo.(contact.Transactional)
+		assert.True(t, ok)
+		assert.Nil(t, repo.WriteClusters(ctx, slices.Values([]contact.ClusterAssignment{{ContactID: "1", ClusterID: 1}})))
+
+		assert.Nil(t, transactional.Begin(ctx))
+		assert.Equal(t, contact.TransactionInProgressError, transactional.Begin(ctx).Error())
+		assert.Nil(t, repo.WriteClusters(ctx, slices.Values([]contact.ClusterAssignment{{ContactID: "2", ClusterID: 2}})))
+		assert.Nil(t, repo.WriteContactMapping(ctx, slices.Values([]contact.ContactMapping{{ContactID: "2", SurvivingContactID: "2"}})))
+		assert.Nil(t, transactional.Rollback(ctx))
+
+		var contactID string
+		var mappings int
+		assert.Nil(t, db.QueryRow("SELECT contact_id FROM clusters").Scan(&contactID))
+		assert.Equal(t, "1", contactID)
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'contact_mapping'").Scan(&mappings))
+		assert.Equal(t, 0, mappings)
+	})
+
+	t.Run("when the run is committed, it should replace every table at once", func(t *testing.T) {
+		db := openDB(t)
+		repo := contact.NewSQLiteRepository(logger, db, contact.DatabaseConfig{ContactsTable: "contacts"}, nil)
+		transactional := repo.(contact.Transactional)
+
+		assert.Nil(t, transactional.Begin(ctx))
+		assert.Nil(t, repo.WriteClusters(ctx, slices.Values([]contact.ClusterAssignment{{ContactID: "2", ClusterID: 2}})))
+		assert.Nil(t, repo.WriteContactMapping(ctx, slices.Values([]contact.ContactMapping{{ContactID: "2", SurvivingContactID: "2"}})))
+		assert.Nil(t, transactional.Commit(ctx))
+
+		var clusters, mappings int
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*) FROM clusters").Scan(&clusters))
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*) FROM contact_mapping").Scan(&mappings))
+		assert.Equal(t, 1, clusters)
+		assert.Equal(t, 1, mappings)
+	})
+}
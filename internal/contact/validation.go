@@ -0,0 +1,267 @@
+package contact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	UnknownZipCountryError = "unknown zip code country"
+)
+
+// ValidationConfig drops the values that are not valid for their field before scoring, so placeholders and garbage
+// don't generate false matches. The outputs keep the original values.
+type ValidationConfig struct {
+	Enabled bool `json:"enabled"`
+	// ZipCountry is the ISO 3166 code of the country whose postal code format zip codes must follow, e.g. US.
+	// Empty accepts any postal code of 3 to 10 letters, digits, spaces or hyphens.
+	ZipCountry string `json:"zip_country"`
+	// Placeholders are the values meaning a field is unknown, compared ignoring case, empty uses defaultPlaceholders.
+	// An email is also a placeholder when its mailbox is one.
+	Placeholders []string `json:"placeholders"`
+	// NamePlaceholders are the placeholders of the first and last names, empty uses Placeholders when they are set or
+	// defaultNamePlaceholders, which leave out the values that are also real names such as Na or Test.
+	NamePlaceholders []string `json:"name_placeholders"`
+}
+
+// equal tells whether both configs drop the same values
+func (c ValidationConfig) equal(other ValidationConfig) bool {
+	return c.Enabled == other.Enabled && strings.EqualFold(c.ZipCountry, other.ZipCountry) &&
+		slices.Equal(c.Placeholders, other.Placeholders) && slices.Equal(c.NamePlaceholders, other.NamePlaceholders)
+}
+
+// Validity tells whether a value can be compared
+type Validity string
+
+const (
+	ValidValue       Validity = "valid"
+	MissingValue     Validity = "missing"
+	PlaceholderValue Validity = "placeholder"
+	InvalidValue     Validity = "invalid"
+)
+
+// FieldQuality counts the values of a field by validity
+type FieldQuality struct {
+	Field       Field `json:"field"`
+	Values      int   `json:"values"`
+	Valid       int   `json:"valid"`
+	Missing     int   `json:"missing"`
+	Placeholder int   `json:"placeholder"`
+	Invalid     int   `json:"invalid"`
+}
+
+var defaultPlaceholders = []string{
+	"n/a", "na", "none", "null", "nil", "unknown", "test", "tbd", "todo", "xxx", "asdf", "-", "--", "?", ".", "0",
+	"noemail", "no email", "noreply", "no-reply", "not available", "not provided", "00000", "99999",
+}
+
+var defaultNamePlaceholders = []string{
+	"n/a", "none", "null", "unknown", "tbd", "todo", "xxx", "asdf", "-", "--", "?", ".", "0", "noname", "no name",
+	"not available", "not provided",
+}
+
+// zipCodeFormats are the postal code formats by country
+var zipCodeFormats = map[string]*regexp.Regexp{
+	"":   regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{1,8}[A-Za-z0-9]$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"CA": regexp.MustCompile(`^[A-Za-z]\d[A-Za-z][ -]?\d[A-Za-z]\d$`),
+	"GB": regexp.MustCompile(`^[A-Za-z]{1,2}\d[A-Za-z\d]? ?\d[A-Za-z]{2}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"MX": regexp.MustCompile(`^\d{5}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Za-z]{2}$`),
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"BR": regexp.MustCompile(`^\d{5}-?\d{3}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+	"AR": regexp.MustCompile(`^([A-Za-z]\d{4}[A-Za-z]{3}|\d{4})$`),
+}
+
+var (
+	emailFormat = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+/=?^_{|}~-]+(\.[A-Za-z0-9!#$%&'*+/=?^_{|}~-]+)*@([A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,}$`)
+	// Names are letters with the spaces, apostrophes, hyphens and periods of compound names and initials
+	nameFormat = regexp.MustCompile(`^\p{L}[\p{L}\p{M} '’.-]*$`)
+)
+
+// validator classifies the values of the compared fields, a disabled validator only tells missing values apart
+type validator struct {
+	enabled          bool
+	zipCode          *regexp.Regexp
+	placeholders     map[string]bool
+	namePlaceholders map[string]bool
+}
+
+func newValidator(config ValidationConfig) (validator, error) {
+	if !config.Enabled {
+		return validator{}, nil
+	}
+
+	zipCode, ok := zipCodeFormats[strings.ToUpper(config.ZipCountry)]
+	if !ok {
+		return validator{}, fmt.Errorf("%s: %q", UnknownZipCountryError, config.ZipCountry)
+	}
+
+	placeholders, namePlaceholders := config.Placeholders, config.NamePlaceholders
+	if len(placeholders) == 0 {
+		placeholders = defaultPlaceholders
+		if len(namePlaceholders) == 0 {
+			namePlaceholders = defaultNamePlaceholders
+		}
+	}
+	if len(namePlaceholders) == 0 {
+		namePlaceholders = placeholders
+	}
+
+	return validator{
+		enabled:          true,
+		zipCode:          zipCode,
+		placeholders:     placeholderSet(placeholders),
+		namePlaceholders: placeholderSet(namePlaceholders),
+	}, nil
+}
+
+func placeholderSet(placeholders []string) map[string]bool {
+	set := make(map[string]bool, len(placeholders))
+	for _, placeholder := range placeholders {
+		set[strings.ToLower(strings.TrimSpace(placeholder))] = true
+	}
+	return set
+}
+
+// check classifies the value of the field, values are checked without their surrounding spaces
+func (v validator) check(field Field, value string) Validity {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return MissingValue
+	}
+	if !v.enabled {
+		return ValidValue
+	}
+
+	if v.isPlaceholder(field, value) {
+		return PlaceholderValue
+	}
+
+	var valid bool
+	switch field {
+	case FirstNameField, LastNameField:
+		valid = nameFormat.MatchString(value)
+	case EmailField:
+		valid = emailFormat.MatchString(value)
+	case ZipCodeField:
+		valid = v.zipCode.MatchString(value)
+	default:
+		valid = true
+	}
+
+	if !valid {
+		return InvalidValue
+	}
+	return ValidValue
+}
+
+func (v validator) isPlaceholder(field Field, value string) bool {
+	value = strings.ToLower(value)
+	if field == FirstNameField || field == LastNameField {
+		return v.namePlaceholders[value]
+	}
+	if v.placeholders[value] {
+		return true
+	}
+
+	if field == EmailField {
+		mailbox, _, found := strings.Cut(value, "@")
+		return found && v.placeholders[mailbox]
+	}
+	return false
+}
+
+// invalidFields lists the compared fields of the contact holding a placeholder or an invalid value
+func (v validator) invalidFields(contact Contact) []Field {
+	if !v.enabled {
+		return nil
+	}
+
+	var invalid []Field
+	for _, field := range comparedFields {
+		validity := v.check(field, fieldValue(contact, field))
+		if validity == PlaceholderValue || validity == InvalidValue {
+			invalid = append(invalid, field)
+		}
+	}
+	return invalid
+}
+
+// report counts the values of every compared field by validity
+func (v validator) report(contacts []Contact) []FieldQuality {
+	report := make([]FieldQuality, len(comparedFields))
+	for i, field := range comparedFields {
+		report[i] = FieldQuality{Field: field, Values: len(contacts)}
+		for _, contact := range contacts {
+			switch v.check(field, fieldValue(contact, field)) {
+			case ValidValue:
+				report[i].Valid++
+			case MissingValue:
+				report[i].Missing++
+			case PlaceholderValue:
+				report[i].Placeholder++
+			case InvalidValue:
+				report[i].Invalid++
+			}
+		}
+	}
+	return report
+}
+
+// logQualityReport logs the fields holding values that were not compared, as they may hide matches
+func logQualityReport(log *logrus.Logger, report []FieldQuality) {
+	for _, quality := range report {
+		if quality.Placeholder > 0 || quality.Invalid > 0 {
+			log.Warnf("field %s has %d placeholder and %d invalid values out of %d, they are compared as missing",
+				quality.Field, quality.Placeholder, quality.Invalid, quality.Values)
+		}
+	}
+}
+
+// dropInvalid empties the values of the invalid fields, so they are compared as missing
+func dropInvalid(contact Contact, invalid []Field) Contact {
+	if slices.Contains(invalid, FirstNameField) {
+		contact.FirstName = ""
+	}
+	if slices.Contains(invalid, LastNameField) {
+		contact.LastName = ""
+	}
+	if slices.Contains(invalid, EmailField) {
+		contact.Email = ""
+	}
+	if slices.Contains(invalid, ZipCodeField) {
+		contact.ZipCode = ""
+	}
+	if slices.Contains(invalid, AddressField) {
+		contact.Address = ""
+	}
+	return contact
+}
+
+func fieldValue(contact Contact, field Field) string {
+	switch field {
+	case FirstNameField:
+		return contact.FirstName
+	case LastNameField:
+		return contact.LastName
+	case EmailField:
+		return contact.Email
+	case ZipCodeField:
+		return contact.ZipCode
+	case AddressField:
+		return contact.Address
+	default:
+		return ""
+	}
+}
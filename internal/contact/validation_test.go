@@ -0,0 +1,142 @@
+package contact_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContactService_EvaluateValidation(t *testing.T) {
+	logger := logrus.New()
+	// Both contacts only share a placeholder email
+	placeholders := []contact.Contact{
+		{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "N/A", ZipCode: "12345", Address: "123 Main St"},
+		{ContactID: "2", FirstName: "Mary", LastName: "Major", Email: "n/a", ZipCode: "54321", Address: "9 Elm St"},
+	}
+
+	t.Run("when validation is disabled, it should credit placeholders like any value", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(placeholders, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{NormalizeEmails: true}
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Len(t, results, 2)
+		mockRepo.AssertNotCalled(t, "WriteQualityReport", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when validation is enabled, it should compare placeholders as missing and keep them in the outputs", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		contacts := append(placeholders, contact.Contact{
+			ContactID: "3", FirstName: "John", LastName: "Doe", Email: "N/A", ZipCode: "12345", Address: "123 Main St",
+		})
+		mockRepo.On("GetContactData", mock.Anything).Return(contacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteExplanations", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{Validation: contact.ValidationConfig{Enabled: true}, Explain: true}
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, "1", results[0].ContactIDSource)
+		assert.Equal(t, "3", results[0].ContactIDMatch)
+		assert.Equal(t, contact.EmailField, results[0].Explanation[2].Field)
+		assert.Equal(t, contact.MissingFieldMatch, results[0].Explanation[2].Match)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("when validation is enabled, it should report the values of every field by validity", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		contacts := []contact.Contact{
+			{ContactID: "1", FirstName: "John", LastName: "Doe", Email: "john@example.com", ZipCode: "12345", Address: "123 Main St"},
+			{ContactID: "2", FirstName: "J0hn", LastName: "O'Neil-Smith", Email: "jdoe@localhost", ZipCode: "1234", Address: "unknown"},
+			{ContactID: "3", FirstName: "José", LastName: "", Email: "noreply@example.com", ZipCode: "12345-6789", Address: "9 Elm St"},
+			{ContactID: "4", FirstName: "Ann", LastName: "Lee", Email: "", ZipCode: "N/A", Address: ""},
+		}
+		mockRepo.On("GetContactData", mock.Anything).Return(contacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{Validation: contact.ValidationConfig{Enabled: true, ZipCountry: "us"}}
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertCalled(t, "WriteQualityReport", mock.Anything, []contact.FieldQuality{
+			{Field: contact.FirstNameField, Values: 4, Valid: 3, Invalid: 1},
+			{Field: contact.LastNameField, Values: 4, Valid: 3, Missing: 1},
+			{Field: contact.EmailField, Values: 4, Valid: 1, Missing: 1, Placeholder: 1, Invalid: 1},
+			{Field: contact.ZipCodeField, Values: 4, Valid: 2, Placeholder: 1, Invalid: 1},
+			{Field: contact.AddressField, Values: 4, Valid: 2, Missing: 1, Placeholder: 1},
+		})
+	})
+
+	t.Run("when the placeholders are configured, it should only treat them as placeholders", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		contacts := []contact.Contact{
+			{ContactID: "1", FirstName: "Unknown", LastName: "Doe", Email: "none@example.com", ZipCode: "A1B 2C3", Address: "N/A"},
+		}
+		mockRepo.On("GetContactData", mock.Anything).Return(contacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{Validation: contact.ValidationConfig{Enabled: true, ZipCountry: "CA", Placeholders: []string{"none"}}}
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertCalled(t, "WriteQualityReport", mock.Anything, []contact.FieldQuality{
+			{Field: contact.FirstNameField, Values: 1, Valid: 1},
+			{Field: contact.LastNameField, Values: 1, Valid: 1},
+			{Field: contact.EmailField, Values: 1, Placeholder: 1},
+			{Field: contact.ZipCodeField, Values: 1, Valid: 1},
+			{Field: contact.AddressField, Values: 1, Valid: 1},
+		})
+	})
+
+	t.Run("when a name is also a placeholder of the other fields, it should keep the name valid", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+		contacts := []contact.Contact{
+			{ContactID: "1", FirstName: "Test", LastName: "Na", Email: "na@example.com", ZipCode: "12345", Address: "test"},
+			{ContactID: "2", FirstName: "Unknown", LastName: "Nil", Email: "nil@example.com", ZipCode: "12345", Address: "1 Main St"},
+		}
+		mockRepo.On("GetContactData", mock.Anything).Return(contacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.Config{Validation: contact.ValidationConfig{Enabled: true}}
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.Nil(t, err)
+		mockRepo.AssertCalled(t, "WriteQualityReport", mock.Anything, []contact.FieldQuality{
+			{Field: contact.FirstNameField, Values: 2, Valid: 1, Placeholder: 1},
+			{Field: contact.LastNameField, Values: 2, Valid: 2},
+			{Field: contact.EmailField, Values: 2, Placeholder: 2},
+			{Field: contact.ZipCodeField, Values: 2, Valid: 2},
+			{Field: contact.AddressField, Values: 2, Valid: 1, Placeholder: 1},
+		})
+	})
+
+	t.Run("when the zip code country is not known, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		config := contact.Config{Validation: contact.ValidationConfig{Enabled: true, ZipCountry: "XX"}}
+		_, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, contact.UnknownZipCountryError+`: "XX"`, err.Error())
+		mockRepo.AssertNotCalled(t, "GetContactData", mock.Anything)
+	})
+}
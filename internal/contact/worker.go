@@ -0,0 +1,160 @@
+package contact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	InvalidWorkersError = "invalid number of workers"
+)
+
+// pairsPerChunk is the number of candidate pairs a worker scores at a time
+const pairsPerChunk = 1024
+
+func validateWorkers(workers int) error {
+	if workers < 0 {
+		return fmt.Errorf("%s: %d, it must be 0 or greater", InvalidWorkersError, workers)
+	}
+	return nil
+}
+
+// uniquePairs drops the pairs whose contact IDs were already compared, keeping the first one in order
+func uniquePairs(records []record, pairs []pair) []pair {
+	comparedPairs := make(map[string]bool)
+	unique := pairs[:0:0]
+	for _, p := range pairs {
+		pairKey := generatePairKey(records[p.i].key(), records[p.j].key())
+		if comparedPairs[pairKey] {
+			continue
+		}
+		comparedPairs[pairKey] = true
+		unique = append(unique, p)
+	}
+	return unique
+}
+
+// scoreProgress resumes the scoring after the pairs already scored and saves the progress every interval pairs.
+// Each save gets the matches found since the previous one, along with the number of matches so far.
+// A nil save disables the checkpoints.
+type scoreProgress struct {
+	scoredPairs int
+	matches     []match
+	interval    int
+	save        func(ctx context.Context, scoredPairs, matchCount int, newMatches []match) error
+}
+
+// scorePairs compares the candidate pairs on the given number of workers, stopping between chunks when the context is done.
+// The pairs are split in chunks and the matches of each chunk are merged in chunk order, so the result is the same as a sequential run.
+func scorePairs(ctx context.Context, s pairScorer, records []record, pairs []pair, workers int, progress scoreProgress) ([]match, error) {
+	remaining := pairs[progress.scoredPairs:]
+	chunks := (len(remaining) + pairsPerChunk - 1) / pairsPerChunk
+	chunkMatches := make([][]match, chunks)
+	scoreIndex := func(index int) {
+		start := index * pairsPerChunk
+		end := min(start+pairsPerChunk, len(remaining))
+		chunkMatches[index] = scoreChunk(s, records, remaining[start:end])
+	}
+
+	// matchesUntil returns the matches of every pair scored before the chunk
+	matchesUntil := func(chunk int) []match {
+		return append(append([]match{}, progress.matches...), concatMatches(chunkMatches[:chunk])...)
+	}
+	scoredUntil := func(chunk int) int {
+		return progress.scoredPairs + min(chunk*pairsPerChunk, len(remaining))
+	}
+	// saveUntil saves the progress up to the chunk, with the matches of the chunks since the last save
+	saveUntil := func(ctx context.Context, saved, chunk int) error {
+		matchCount := len(progress.matches)
+		for _, matches := range chunkMatches[:chunk] {
+			matchCount += len(matches)
+		}
+		return progress.save(ctx, scoredUntil(chunk), matchCount, concatMatches(chunkMatches[saved:chunk]))
+	}
+
+	indexes := make(chan int)
+	done := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(workers, chunks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				scoreIndex(index)
+				done <- index
+			}
+		}()
+	}
+
+	// The chunks before the cursor are scored, the progress saved is always a prefix of the candidate pairs
+	completed := make([]bool, chunks)
+	cursor, saved, next := 0, 0, 0
+	var err error
+	for cursor < chunks && err == nil {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		var send chan int
+		if next < chunks {
+			send = indexes
+		}
+
+		select {
+		case send <- next:
+			next++
+		case index := <-done:
+			completed[index] = true
+			for cursor < chunks && completed[cursor] {
+				cursor++
+			}
+
+			if progress.save != nil && cursor < chunks && scoredUntil(cursor)-scoredUntil(saved) >= progress.interval {
+				err = saveUntil(ctx, saved, cursor)
+				saved = cursor
+			}
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	close(indexes)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for range done {
+	}
+
+	if ctx.Err() != nil && progress.save != nil && cursor > saved {
+		// Keep the progress of an interrupted run, the context is already done so the checkpoint is saved without it
+		if saveErr := saveUntil(context.WithoutCancel(ctx), saved, cursor); saveErr != nil {
+			return nil, errors.Join(err, saveErr)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return matchesUntil(chunks), nil
+}
+
+func concatMatches(chunkMatches [][]match) []match {
+	var matches []match
+	for _, chunk := range chunkMatches {
+		matches = append(matches, chunk...)
+	}
+	return matches
+}
+
+func scoreChunk(s pairScorer, records []record, pairs []pair) []match {
+	var matches []match
+	for _, p := range pairs {
+		if result, ok := compareContacts(s, records[p.i], records[p.j]); ok {
+			matches = append(matches, match{pair: p, comparison: result})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,101 @@
+package contact_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContactService_EvaluateWorkers(t *testing.T) {
+	logger := logrus.New()
+	mockContacts := generatedContacts(120)
+
+	evaluate := func(workers int) []contact.ProcessOutput {
+		mockRepo := new(mocks.RepositoryMock)
+		mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+		mockRepo.On("WriteContactData", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteDuplicateContacts", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("WriteQualityReport", mock.Anything, mock.Anything).Return(nil)
+
+		config := contact.DefaultConfig()
+		config.Clustering.Enabled = false
+		config.Merge.Enabled = false
+		config.Workers = workers
+
+		results, err := collect(contact.NewContactService(logger, mockRepo, config).Evaluate(context.Background()))
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+		return results
+	}
+
+	t.Run("when scoring on several workers, it should return the same output as a sequential run", func(t *testing.T) {
+		sequential := evaluate(1)
+
+		assert.NotEmpty(t, sequential)
+		assert.Equal(t, sequential, evaluate(4))
+		assert.Equal(t, sequential, evaluate(32))
+	})
+
+	t.Run("when the context is cancelled, it should stop scoring without writing any output", func(t *testing.T) {
+		for _, workers := range []int{1, 4} {
+			mockRepo := new(mocks.RepositoryMock)
+			mockRepo.On("GetContactData", mock.Anything).Return(mockContacts, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := collect(contact.NewContactService(logger, mockRepo, contact.Config{Workers: workers}).Evaluate(ctx))
+
+			assert.ErrorIs(t, err, context.Canceled)
+			mockRepo.AssertExpectations(t)
+		}
+	})
+
+	t.Run("when the number of workers is negative, it should return an error", func(t *testing.T) {
+		mockRepo := new(mocks.RepositoryMock)
+
+		service := contact.NewContactService(logger, mockRepo, contact.Config{Workers: -1})
+		_, err := collect(service.Evaluate(context.Background()))
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.InvalidWorkersError)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// BenchmarkScorePairs scores the candidate pairs of a few thousand contacts, blocked by zip code in blocks of 50,
+// with several worker counts
+func BenchmarkScorePairs(b *testing.B) {
+	contacts := generatedContacts(3000)
+	for i := range contacts {
+		contacts[i].ZipCode = fmt.Sprint(10000 + i/50)
+	}
+
+	config := contact.DefaultConfig()
+	config.BlockKeys = []string{contact.ZipBlockKey}
+	score, err := contact.PrepareScoring(contacts, config)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	workerCounts := []int{1, 2, 4}
+	if runtime.NumCPU() > 4 {
+		workerCounts = append(workerCounts, runtime.NumCPU())
+	}
+
+	for _, workers := range workerCounts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for range b.N {
+				if err := score(context.Background(), workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
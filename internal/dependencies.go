@@ -1,24 +1,151 @@
 package internal
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/sebastianreh/compass-code-assessment/internal/contact"
 	"github.com/sebastianreh/compass-code-assessment/pkg"
 	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
 )
 
 type Dependencies struct {
 	Logger  *logrus.Logger
 	Service contact.Service
+	// Config and Columns are the settings the service was built with, the server builds its batch services from them
+	Config  contact.Config
+	Columns contact.ColumnMapping
+
+	// closeDB releases the database connection, nil when reading files
+	closeDB func() error
 }
 
-func Build() Dependencies {
+func Build(ctx context.Context, options Options) (Dependencies, error) {
 	logger := logrus.New()
 	csvConnector := pkg.NewCSVConnector()
-	repository := contact.NewContactRepository(logger, csvConnector)
-	service := contact.NewContactService(logger, repository)
+	jsonConnector := pkg.NewJSONConnector()
+
+	columns := contact.DefaultColumnMapping()
+	if options.ColumnsFile != "" {
+		var err error
+		columns, err = contact.LoadColumnMapping(ctx, csvConnector, options.ColumnsFile)
+		if err != nil {
+			return Dependencies{}, fmt.Errorf("error loading column mapping: %w", err)
+		}
+	}
+
+	config := contact.DefaultConfig()
+	if options.ConfigFile != "" {
+		var err error
+		config, err = contact.LoadConfig(ctx, jsonConnector, options.ConfigFile)
+		if err != nil {
+			return Dependencies{}, fmt.Errorf("error loading config: %w", err)
+		}
+	}
+
+	if options.NicknamesFile != "" {
+		nicknames, err := contact.LoadNicknameTable(ctx, csvConnector, options.NicknamesFile)
+		if err != nil {
+			return Dependencies{}, fmt.Errorf("error loading nicknames: %w", err)
+		}
+		config.Nicknames = nicknames
+	}
+
+	if options.Explain {
+		config.Explain = true
+	}
+	if options.ScoringModel != "" {
+		config.ScoringModel = options.ScoringModel
+	}
+	if len(options.BlockKeys) > 0 {
+		config.BlockKeys = options.BlockKeys
+	}
+	if options.ZipCountry != "" {
+		config.Validation.ZipCountry = options.ZipCountry
+	}
+	if options.MaxRejects > 0 {
+		config.MaxRejects = options.MaxRejects
+	}
+	if options.Incremental {
+		config.Incremental = true
+	}
+	if options.CheckpointInterval > 0 {
+		config.CheckpointInterval = options.CheckpointInterval
+	}
+	if options.Resume {
+		config.Resume = true
+	}
+	// The contact IDs of both datasets may overlap, so linking leaves out the clusters and golden records
+	inputPaths := []string{options.Paths.Input}
+	if options.Paths.Reference != "" || options.Database.Linking() {
+		config.Linkage = true
+		config.Clustering.Enabled = false
+		config.Merge.Enabled = false
+	}
+	if options.Paths.Reference != "" {
+		inputPaths = append(inputPaths, options.Paths.Reference)
+	}
+
+	// The checkpoint of a SQLite run is tied to the database, its output tables are only replaced once scoring ends.
+	// A Postgres run has no input file, its checkpoint is tied to the contacts it reads.
+	if options.UsesDatabase() {
+		if err := options.Database.Validate(); err != nil {
+			return Dependencies{}, err
+		}
+	}
+
+	var closeDB func() error
+	var repository contact.Repository
+	switch {
+	case options.SQLiteFile != "":
+		db, err := sql.Open("sqlite", options.SQLiteFile)
+		if err == nil {
+			err = db.PingContext(ctx)
+		}
+		if err != nil {
+			return Dependencies{}, fmt.Errorf("error opening SQLite database: %w", err)
+		}
+
+		repository = contact.NewSQLiteRepository(logger, db, options.Database, columns)
+		inputPaths = []string{options.SQLiteFile}
+		closeDB = db.Close
+	case options.PostgresURL != "":
+		conn, err := pgx.Connect(ctx, options.PostgresURL)
+		if err != nil {
+			return Dependencies{}, fmt.Errorf("error connecting to Postgres: %w", err)
+		}
+
+		repository = contact.NewPostgresRepository(logger, conn, contact.PostgresConfig{DatabaseConfig: options.Database}, columns)
+		inputPaths = nil
+		closeDB = func() error { return conn.Close(context.Background()) }
+	default:
+		repository = contact.NewContactRepository(logger, csvConnector, options.Paths, columns)
+	}
+	if options.IndexFile != "" {
+		config.Index = contact.NewIndexStore(jsonConnector, options.IndexFile)
+	}
+	if options.CheckpointFile != "" {
+		config.Checkpoints = contact.NewCheckpointStore(jsonConnector, options.CheckpointFile, inputPaths...)
+	}
+
+	service := contact.NewContactService(logger, repository, config)
 
 	return Dependencies{
 		Logger:  logger,
 		Service: service,
+		Config:  config,
+		Columns: columns,
+		closeDB: closeDB,
+	}, nil
+}
+
+// Close releases the database, if any
+func (d Dependencies) Close() error {
+	if d.closeDB == nil {
+		return nil
 	}
+	return d.closeDB()
 }
@@ -0,0 +1,63 @@
+package internal_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal"
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configFile, []byte(`{"incremental": true, "checkpoint_interval": 500, "resume": true}`), 0o644)
+	assert.Nil(t, err)
+
+	t.Run("when the flags are not given, it should keep the options of the config file", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{"-config", configFile, "-output-dir", t.TempDir()})
+		assert.Nil(t, err)
+
+		build, err := internal.Build(ctx, options)
+
+		assert.Nil(t, err)
+		assert.True(t, build.Config.Incremental)
+		assert.Equal(t, 500, build.Config.CheckpointInterval)
+		assert.True(t, build.Config.Resume)
+	})
+
+	t.Run("when the flags are given, it should replace the options of the config file", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{"-config", configFile, "-output-dir", t.TempDir(), "-checkpoint-interval", "20"})
+		assert.Nil(t, err)
+
+		build, err := internal.Build(ctx, options)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 20, build.Config.CheckpointInterval)
+	})
+
+	t.Run("when no config file is given, it should take the options from the flags", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{"-output-dir", t.TempDir(), "-incremental", "-resume"})
+		assert.Nil(t, err)
+
+		build, err := internal.Build(ctx, options)
+
+		assert.Nil(t, err)
+		assert.True(t, build.Config.Incremental)
+		assert.True(t, build.Config.Resume)
+		assert.Zero(t, build.Config.CheckpointInterval)
+	})
+
+	t.Run("when an output table is the contacts table, it should return an error", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{"-sqlite", filepath.Join(t.TempDir(), "contacts.db"), "-db-table", "matches"})
+		assert.Nil(t, err)
+
+		_, err = internal.Build(ctx, options)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), contact.OutputTableConflictError)
+	})
+}
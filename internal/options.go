@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+)
+
+const (
+	OutputExistsError     = "output file already exists, use -overwrite to replace it"
+	DatabaseConflictError = "only one of -sqlite and -postgres can be given"
+)
+
+// Options holds the command line arguments of the application
+type Options struct {
+	Paths     contact.Paths
+	Overwrite bool
+	// ColumnsFile is the optional "field,column" CSV file mapping the input header to the contact fields
+	ColumnsFile string
+	// NicknamesFile is the optional "name,nickname" CSV file extending the embedded nickname dictionary
+	NicknamesFile string
+	// Timeout cancels the processing when it takes longer, 0 means no limit
+	Timeout time.Duration
+	// CheckpointFile keeps the scoring progress, an empty path disables the checkpoints
+	CheckpointFile string
+	// CheckpointInterval is the number of candidate pairs scored between checkpoints, 0 keeps the interval of the config
+	CheckpointInterval int
+	// Resume continues from the checkpoint of an interrupted run, false keeps the option of the config
+	Resume bool
+	// IndexFile keeps the match index of the last run, an empty path disables the index
+	IndexFile string
+	// Incremental matches the new and changed contacts against the match index, false keeps the option of the config
+	Incremental bool
+	// ConfigFile is the optional JSON file with the scoring options, see contact.LoadConfig
+	ConfigFile string
+	// Explain writes the breakdown of every match score by field
+	Explain bool
+	// ScoringModel selects how the pairs are scored, empty keeps the model of the config, see contact.ScoringModel
+	ScoringModel contact.ScoringModel
+	// BlockKeys are the blocking keys of the candidate pairs, empty keeps the ones of the config, see contact.Config
+	BlockKeys []string
+	// ZipCountry is the country whose postal code format zip codes are validated against, empty keeps the one of the config
+	ZipCountry string
+	// MaxRejects fails the run when more input rows are rejected, 0 keeps the limit of the config
+	MaxRejects int
+	// SQLiteFile is the database the contacts are read from and the outputs written to instead of files, when not empty
+	SQLiteFile string
+	// PostgresURL is the Postgres database used instead of files, when not empty
+	PostgresURL string
+	// Database selects the tables or queries the contacts are read from, in the SQLite or Postgres database
+	Database contact.DatabaseConfig
+}
+
+// ParseOptions reads the command line arguments, output and duplicates paths are relative to the output directory
+func ParseOptions(args []string) (Options, error) {
+	flags := flag.NewFlagSet("compass", flag.ContinueOnError)
+	input := flags.String("input", filepath.Join("files", "input.csv"), "path of the contacts CSV file")
+	reference := flags.String("reference", "", "optional contacts CSV file the input is linked to, only pairs across both files are compared")
+	outputDir := flags.String("output-dir", "files", "directory where every output file is written")
+	format := flags.String("format", "csv", "format of the output files, csv, json or ndjson, the input format follows its extension")
+	output := flags.String("output", "", "path of the matches file, output.<format> by default, its extension sets its format")
+	duplicates := flags.String("duplicates", "", "path of the duplicate contacts file, duplicate.<format> by default, its extension sets its format")
+	columns := flags.String("columns", "", "optional \"field,column\" CSV file mapping the input header to the contact fields")
+	nicknames := flags.String("nicknames", "", "optional \"name,nickname\" CSV file with aliases added to the embedded nickname dictionary")
+	timeout := flags.Duration("timeout", 0, "cancel the processing when it takes longer than this duration, e.g. 10m")
+	checkpoint := flags.String("checkpoint", "checkpoint.json", "path of the checkpoint file relative to the output directory, empty disables the checkpoints")
+	checkpointInterval := flags.Int("checkpoint-interval", 0, "number of candidate pairs scored between checkpoints, 0 keeps the interval of the config file, 100000 by default")
+	resume := flags.Bool("resume", false, "continue from the checkpoint of an interrupted run of the same input file, replaces resume of the config file")
+	config := flags.String("config", "", "optional JSON file with the options replacing the defaults, such as the field weights, comparators and accuracy cutoffs")
+	explain := flags.Bool("explain", false, "write the breakdown of every match score by field to explanations.csv")
+	index := flags.String("index", "", "path of the match index relative to the output directory, saved after every run")
+	incremental := flags.Bool("incremental", false, "only match the new and changed contacts against the match index, replaces incremental of the config file")
+	model := flags.String("model", "", "scoring model, heuristic or fellegi_sunter, replaces the model of the config file")
+	sqlite := flags.String("sqlite", "", "optional SQLite database the contacts are read from and the outputs are written to, instead of files")
+	postgres := flags.String("postgres", "", "optional Postgres URL of the database the contacts are read from and the outputs are written to, instead of files")
+	dbTable := flags.String("db-table", "contacts", "table of the database the contacts are read from")
+	dbQuery := flags.String("db-query", "", "query selecting the contacts from the database, replaces -db-table")
+	dbReferenceTable := flags.String("db-reference-table", "", "optional table of the database the contacts are linked to")
+	dbReferenceQuery := flags.String("db-reference-query", "", "optional query selecting the contacts the input is linked to, replaces -db-reference-table")
+	dbOutputPrefix := flags.String("db-output-prefix", "", "prefix of the output tables written to the database, e.g. dedup_ writes the matches to dedup_matches")
+	blockKeys := flags.String("block-keys", "", "comma separated blocking keys, zip, email_domain, last_initial or last_soundex, replaces the keys of the config file")
+	zipCountry := flags.String("zip-country", "", "ISO 3166 code of the country whose postal code format zip codes must follow, e.g. US")
+	maxRejects := flags.Int("max-rejects", 0, "fail the run when more input rows are rejected, 0 keeps the limit of the config file, none by default")
+	overwrite := flags.Bool("overwrite", true, "replace the output files when they already exist, set to false to fail instead")
+
+	if err := flags.Parse(args); err != nil {
+		return Options{}, err
+	}
+
+	if flags.NArg() > 0 {
+		return Options{}, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	if *sqlite != "" && *postgres != "" {
+		return Options{}, errors.New(DatabaseConflictError)
+	}
+
+	outputFormat, err := pkg.ParseFormat(*format)
+	if err != nil {
+		return Options{}, err
+	}
+
+	paths := contact.FormatOutputPaths(*input, *outputDir, outputFormat)
+	paths.Reference = *reference
+	if *output != "" {
+		paths.Output = outputPath(*outputDir, *output)
+	}
+	if *duplicates != "" {
+		paths.Duplicates = outputPath(*outputDir, *duplicates)
+	}
+
+	var checkpointFile string
+	if *checkpoint != "" {
+		checkpointFile = outputPath(*outputDir, *checkpoint)
+	}
+
+	var indexFile string
+	if *index != "" {
+		indexFile = outputPath(*outputDir, *index)
+	}
+
+	return Options{
+		Paths:         paths,
+		Overwrite:     *overwrite,
+		ColumnsFile:   *columns,
+		NicknamesFile: *nicknames,
+		Timeout:       *timeout,
+
+		CheckpointFile:     checkpointFile,
+		CheckpointInterval: *checkpointInterval,
+		Resume:             *resume,
+
+		IndexFile:   indexFile,
+		Incremental: *incremental,
+
+		ConfigFile:   *config,
+		Explain:      *explain,
+		ScoringModel: contact.ScoringModel(*model),
+		BlockKeys:    splitList(*blockKeys),
+		ZipCountry:   *zipCountry,
+		MaxRejects:   *maxRejects,
+
+		SQLiteFile:  *sqlite,
+		PostgresURL: *postgres,
+		Database: contact.DatabaseConfig{
+			ContactsTable:  *dbTable,
+			ContactsQuery:  *dbQuery,
+			ReferenceTable: *dbReferenceTable,
+			ReferenceQuery: *dbReferenceQuery,
+			OutputPrefix:   *dbOutputPrefix,
+		},
+	}, nil
+}
+
+// ServerOptions holds the command line arguments of the HTTP server
+type ServerOptions struct {
+	Options
+	// Addr is the address the server listens on
+	Addr string
+	// ShutdownTimeout is how long the requests in flight have to finish once the server is stopped
+	ShutdownTimeout time.Duration
+}
+
+// ParseServerOptions reads the command line arguments of the HTTP server, lookups are matched against the input file
+// or, when it exists, the match index
+func ParseServerOptions(args []string) (ServerOptions, error) {
+	flags := flag.NewFlagSet("compass-server", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address the server listens on")
+	input := flags.String("input", filepath.Join("files", "input.csv"), "path of the contacts CSV file the lookups are matched against")
+	index := flags.String("index", "", "optional match index the lookups are matched against instead of the input file")
+	columns := flags.String("columns", "", "optional \"field,column\" CSV file mapping the input and uploaded headers to the contact fields")
+	nicknames := flags.String("nicknames", "", "optional \"name,nickname\" CSV file with aliases added to the embedded nickname dictionary")
+	config := flags.String("config", "", "optional JSON file with the options replacing the defaults, such as the field weights, comparators and accuracy cutoffs")
+	explain := flags.Bool("explain", false, "break the score of every candidate and match down by field")
+	model := flags.String("model", "", "scoring model, heuristic or fellegi_sunter, replaces the model of the config file")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 10*time.Second, "time the requests in flight have to finish once the server is stopped")
+
+	if err := flags.Parse(args); err != nil {
+		return ServerOptions{}, err
+	}
+
+	if flags.NArg() > 0 {
+		return ServerOptions{}, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	return ServerOptions{
+		Options: Options{
+			Paths:         contact.Paths{Input: *input},
+			ColumnsFile:   *columns,
+			NicknamesFile: *nicknames,
+			IndexFile:     *index,
+			ConfigFile:    *config,
+			Explain:       *explain,
+			ScoringModel:  contact.ScoringModel(*model),
+		},
+		Addr:            *addr,
+		ShutdownTimeout: *shutdownTimeout,
+	}, nil
+}
+
+// PrepareOutputs creates the output directories and, unless overwriting, fails when any output file already exists.
+// The output tables of a database run are always replaced.
+func (o Options) PrepareOutputs() error {
+	for _, path := range o.Paths.Outputs() {
+		if !o.Overwrite && !o.UsesDatabase() {
+			_, err := os.Stat(path)
+			if err == nil {
+				return fmt.Errorf("%s: %s", OutputExistsError, path)
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("error checking output file: %w", err)
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("error creating output directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UsesDatabase tells whether the contacts are read from a database instead of files
+func (o Options) UsesDatabase() bool {
+	return o.SQLiteFile != "" || o.PostgresURL != ""
+}
+
+// splitList splits a comma separated flag, an empty flag is an empty list
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func outputPath(outputDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(outputDir, path)
+}
@@ -0,0 +1,198 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/compass-code-assessment/internal"
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOptions(t *testing.T) {
+	t.Run("when no flags are given, it should use the files folder", func(t *testing.T) {
+		options, err := internal.ParseOptions(nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, contact.DefaultPaths(), options.Paths)
+		assert.True(t, options.Overwrite)
+		assert.Empty(t, options.ConfigFile)
+		assert.Empty(t, options.ScoringModel)
+		assert.Empty(t, options.BlockKeys)
+	})
+
+	t.Run("when the paths are given, it should place relative outputs inside the output directory", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{
+			"-input", "data/contacts.csv",
+			"-reference", "data/crm.csv",
+			"-output-dir", "results",
+			"-output", "matches.csv",
+			"-duplicates", "/tmp/duplicates.csv",
+			"-overwrite=false",
+			"-timeout", "90s",
+			"-model", "fellegi_sunter",
+			"-config", "config.json",
+			"-explain",
+			"-index", "index.json",
+			"-incremental",
+			"-max-rejects", "10",
+			"-zip-country", "CA",
+			"-block-keys", "zip, last_initial",
+			"-nicknames", "aliases.csv",
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "data/contacts.csv", options.Paths.Input)
+		assert.Equal(t, "data/crm.csv", options.Paths.Reference)
+		assert.Equal(t, filepath.Join("results", "matches.csv"), options.Paths.Output)
+		assert.Equal(t, "/tmp/duplicates.csv", options.Paths.Duplicates)
+		assert.Equal(t, filepath.Join("results", "clusters.csv"), options.Paths.Clusters)
+		assert.False(t, options.Overwrite)
+		assert.Equal(t, 90*time.Second, options.Timeout)
+		assert.Equal(t, contact.FellegiSunterModel, options.ScoringModel)
+		assert.Equal(t, "config.json", options.ConfigFile)
+		assert.True(t, options.Explain)
+		assert.Equal(t, filepath.Join("results", "index.json"), options.IndexFile)
+		assert.True(t, options.Incremental)
+		assert.Equal(t, 10, options.MaxRejects)
+		assert.Equal(t, "CA", options.ZipCountry)
+		assert.Equal(t, []string{contact.ZipBlockKey, contact.LastInitialBlockKey}, options.BlockKeys)
+		assert.Equal(t, "aliases.csv", options.NicknamesFile)
+	})
+
+	t.Run("when a format is given, it should name the default output files with its extension", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{"-format", "ndjson", "-output-dir", "results", "-duplicates", "duplicate.csv"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, filepath.Join("results", "output.ndjson"), options.Paths.Output)
+		assert.Equal(t, filepath.Join("results", "duplicate.csv"), options.Paths.Duplicates)
+		assert.Equal(t, filepath.Join("results", "explanations.ndjson"), options.Paths.Explanations)
+	})
+
+	t.Run("when a SQLite database is given, it should read the contacts from the given table and query", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{
+			"-sqlite", "contacts.db",
+			"-db-query", "SELECT * FROM leads",
+			"-db-reference-table", "crm",
+			"-db-output-prefix", "dedup_",
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "contacts.db", options.SQLiteFile)
+		assert.Equal(t, contact.DatabaseConfig{
+			ContactsTable:  "contacts",
+			ContactsQuery:  "SELECT * FROM leads",
+			ReferenceTable: "crm",
+			OutputPrefix:   "dedup_",
+		}, options.Database)
+		assert.True(t, options.Database.Linking())
+		assert.True(t, options.UsesDatabase())
+	})
+
+	t.Run("when a Postgres URL is given, it should read the contacts from the given table", func(t *testing.T) {
+		options, err := internal.ParseOptions([]string{"-postgres", "postgres://localhost/crm", "-db-table", "master.contacts"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "postgres://localhost/crm", options.PostgresURL)
+		assert.Equal(t, "master.contacts", options.Database.ContactsTable)
+		assert.False(t, options.Database.Linking())
+		assert.True(t, options.UsesDatabase())
+	})
+
+	t.Run("when both a SQLite and a Postgres database are given, it should return an error", func(t *testing.T) {
+		_, err := internal.ParseOptions([]string{"-sqlite", "contacts.db", "-postgres", "postgres://localhost/crm"})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, internal.DatabaseConflictError, err.Error())
+	})
+
+	t.Run("when the format is not supported, it should return an error", func(t *testing.T) {
+		_, err := internal.ParseOptions([]string{"-format", "xml"})
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), pkg.UnsupportedFormatError)
+	})
+
+	t.Run("when a flag is unknown, it should return an error", func(t *testing.T) {
+		_, err := internal.ParseOptions([]string{"-unknown"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("when positional arguments are given, it should return an error", func(t *testing.T) {
+		_, err := internal.ParseOptions([]string{"input.csv"})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "unexpected arguments")
+	})
+}
+
+func TestParseServerOptions(t *testing.T) {
+	t.Run("when no flags are given, it should listen on port 8080 and match against the input file", func(t *testing.T) {
+		options, err := internal.ParseServerOptions(nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, ":8080", options.Addr)
+		assert.Equal(t, filepath.Join("files", "input.csv"), options.Paths.Input)
+		assert.Empty(t, options.IndexFile)
+		assert.Equal(t, 10*time.Second, options.ShutdownTimeout)
+	})
+
+	t.Run("when the flags are given, it should keep the index path as given", func(t *testing.T) {
+		options, err := internal.ParseServerOptions([]string{
+			"-addr", "127.0.0.1:9090",
+			"-input", "data/contacts.csv",
+			"-index", "files/index.json",
+			"-model", "fellegi_sunter",
+			"-nicknames", "aliases.csv",
+			"-explain",
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "127.0.0.1:9090", options.Addr)
+		assert.Equal(t, "aliases.csv", options.NicknamesFile)
+		assert.Equal(t, "data/contacts.csv", options.Paths.Input)
+		assert.Equal(t, "files/index.json", options.IndexFile)
+		assert.Equal(t, contact.FellegiSunterModel, options.ScoringModel)
+		assert.True(t, options.Explain)
+	})
+
+	t.Run("when positional arguments are given, it should return an error", func(t *testing.T) {
+		_, err := internal.ParseServerOptions([]string{"input.csv"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestOptions_PrepareOutputs(t *testing.T) {
+	t.Run("when the output directory does not exist, it should create it", func(t *testing.T) {
+		outputDir := filepath.Join(t.TempDir(), "results")
+		options := internal.Options{Paths: contact.OutputPaths("input.csv", outputDir)}
+
+		err := options.PrepareOutputs()
+
+		assert.Nil(t, err)
+		assert.DirExists(t, outputDir)
+	})
+
+	t.Run("when an output file exists and overwrite is disabled, it should return an error", func(t *testing.T) {
+		outputDir := t.TempDir()
+		options := internal.Options{Paths: contact.OutputPaths("input.csv", outputDir)}
+		assert.Nil(t, os.WriteFile(options.Paths.Output, []byte("ContactIDSource\n"), 0o644))
+
+		err := options.PrepareOutputs()
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), internal.OutputExistsError)
+	})
+
+	t.Run("when an output file exists and overwrite is enabled, it should not return an error", func(t *testing.T) {
+		outputDir := t.TempDir()
+		options := internal.Options{Paths: contact.OutputPaths("input.csv", outputDir), Overwrite: true}
+		assert.Nil(t, os.WriteFile(options.Paths.Output, []byte("ContactIDSource\n"), 0o644))
+
+		err := options.PrepareOutputs()
+
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	UnsupportedContentTypeError = "unsupported content type, use application/json, application/x-ndjson or text/csv"
+	InvalidLimitError           = "invalid limit"
+)
+
+const (
+	// maxUploadBytes limits the size of a request body
+	maxUploadBytes = 32 << 20
+	// defaultLimit is the number of candidates returned when the request doesn't set one
+	defaultLimit = 10
+)
+
+// MatchResponse lists the known contacts matching the contact of the request, from the highest accuracy
+type MatchResponse struct {
+	Candidates []contact.Candidate `json:"candidates"`
+}
+
+// BatchResponse holds the outputs of deduplicating the uploaded contacts
+type BatchResponse struct {
+	Matches    []contact.ProcessOutput     `json:"matches"`
+	Duplicates []contact.Contact           `json:"duplicates"`
+	Clusters   []contact.ClusterAssignment `json:"clusters,omitempty"`
+	Merged     []contact.Contact           `json:"merged,omitempty"`
+	Mapping    []contact.ContactMapping    `json:"mapping,omitempty"`
+	Quality    []contact.FieldQuality      `json:"quality,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type handler struct {
+	log     *logrus.Logger
+	service contact.Service
+	config  contact.Config
+	columns contact.ColumnMapping
+}
+
+// NewHandler serves the lookups with the service and deduplicates every uploaded batch with a new service
+// built from config, without checkpoints nor match index. Uploads of every format are read with the columns mapping.
+func NewHandler(log *logrus.Logger, service contact.Service, config contact.Config, columns contact.ColumnMapping) http.Handler {
+	h := &handler{
+		log:     log,
+		service: service,
+		config:  config,
+		columns: columns,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", h.health)
+	mux.HandleFunc("POST /contacts/match", h.match)
+	mux.HandleFunc("POST /contacts/batch", h.batch)
+	return mux
+}
+
+func (h *handler) health(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// match looks up a single contact, the limit query parameter caps the number of candidates
+func (h *handler) match(w http.ResponseWriter, r *http.Request) {
+	limit := defaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			h.writeError(w, http.StatusBadRequest, fmt.Errorf("%s: %q", InvalidLimitError, value))
+			return
+		}
+	}
+
+	var lookup contact.Contact
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadBytes)).Decode(&lookup); err != nil {
+		h.writeError(w, requestErrorStatus(err), fmt.Errorf("error decoding contact: %w", err))
+		return
+	}
+
+	candidates, err := h.service.Match(r.Context(), lookup)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == contact.EmptyContactError {
+			status = http.StatusBadRequest
+		}
+		h.writeError(w, status, err)
+		return
+	}
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	if candidates == nil {
+		candidates = []contact.Candidate{}
+	}
+
+	h.writeJSON(w, http.StatusOK, MatchResponse{Candidates: candidates})
+}
+
+// batch deduplicates the uploaded contacts, a JSON array of contacts, a contact per line or a CSV file with a header
+func (h *handler) batch(w http.ResponseWriter, r *http.Request) {
+	contacts, err := h.readContacts(r, http.MaxBytesReader(w, r.Body, maxUploadBytes))
+	if err != nil {
+		status := requestErrorStatus(err)
+		if err.Error() == UnsupportedContentTypeError {
+			status = http.StatusUnsupportedMediaType
+		}
+		h.writeError(w, status, err)
+		return
+	}
+
+	config := h.config
+	config.Checkpoints = nil
+	config.Resume = false
+	config.Index = nil
+	config.Incremental = false
+	config.Linkage = false
+
+	repository := &memoryRepository{contacts: contacts}
+	if _, err = contact.NewContactService(h.log, repository, config).Evaluate(r.Context()); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := BatchResponse{
+		Matches:    repository.matches,
+		Duplicates: repository.duplicates,
+		Clusters:   repository.clusters,
+		Merged:     repository.merged,
+		Mapping:    repository.mapping,
+		Quality:    repository.quality,
+	}
+	if response.Matches == nil {
+		response.Matches = []contact.ProcessOutput{}
+	}
+	if response.Duplicates == nil {
+		response.Duplicates = []contact.Contact{}
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func (h *handler) readContacts(r *http.Request, body io.Reader) ([]contact.Contact, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, errors.New(UnsupportedContentTypeError)
+	}
+
+	switch mediaType {
+	case "application/json", "application/x-ndjson":
+		format := pkg.JSONFormat
+		if mediaType == "application/x-ndjson" {
+			format = pkg.NDJSONFormat
+		}
+
+		// The reader goes over the objects twice to gather the keys of every object
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading contacts: %w", err)
+		}
+		return contact.ParseContacts(pkg.StreamJSONReader(r.Context(), bytes.NewReader(content), format), h.columns)
+	case "text/csv":
+		return contact.ParseContacts(pkg.StreamCSVReader(r.Context(), body), h.columns)
+	default:
+		return nil, errors.New(UnsupportedContentTypeError)
+	}
+}
+
+func (h *handler) writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		h.log.Errorf("error serving request: %v", err)
+	}
+	h.writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		h.log.Errorf("error writing response: %v", err)
+	}
+}
+
+// requestErrorStatus tells a body over the size limit apart from any other invalid body
+func requestErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,216 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/sebastianreh/compass-code-assessment/internal/server"
+	"github.com/sebastianreh/compass-code-assessment/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandler(t *testing.T) {
+	logger := logrus.New()
+	lookup := contact.Contact{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	candidates := []contact.Candidate{
+		{Contact: contact.Contact{ContactID: "2"}, AccuracyLevel: 5, Accuracy: "Very High", Duplicate: true},
+		{Contact: contact.Contact{ContactID: "1"}, AccuracyLevel: 3, Accuracy: "Medium"},
+	}
+
+	// serve sends the request to a handler on the service, with the batch services built from the default config
+	serve := func(service contact.Service, method, target, contentType, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			request.Header.Set("Content-Type", contentType)
+		}
+		recorder := httptest.NewRecorder()
+		server.NewHandler(logger, service, contact.DefaultConfig(), contact.DefaultColumnMapping()).ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("when checking the health, it should return ok", func(t *testing.T) {
+		response := serve(new(mocks.ServiceMock), http.MethodGet, "/health", "", "")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, response.Body.String())
+	})
+
+	t.Run("when matching a contact, it should return the candidates of the service", func(t *testing.T) {
+		service := new(mocks.ServiceMock)
+		service.On("Match", mock.Anything, lookup).Return(candidates, nil)
+
+		response := serve(service, http.MethodPost, "/contacts/match", "application/json",
+			`{"first_name":"John","last_name":"Doe","email":"john@example.com"}`)
+
+		var body server.MatchResponse
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+		assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+		assert.Equal(t, candidates, body.Candidates)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("when a limit is given, it should return at most that many candidates", func(t *testing.T) {
+		service := new(mocks.ServiceMock)
+		service.On("Match", mock.Anything, lookup).Return(candidates, nil)
+
+		response := serve(service, http.MethodPost, "/contacts/match?limit=1", "application/json",
+			`{"first_name":"John","last_name":"Doe","email":"john@example.com"}`)
+
+		var body server.MatchResponse
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+		assert.Equal(t, candidates[:1], body.Candidates)
+	})
+
+	t.Run("when no contact matches, it should return an empty list", func(t *testing.T) {
+		service := new(mocks.ServiceMock)
+		service.On("Match", mock.Anything, lookup).Return([]contact.Candidate(nil), nil)
+
+		response := serve(service, http.MethodPost, "/contacts/match", "application/json",
+			`{"first_name":"John","last_name":"Doe","email":"john@example.com"}`)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"candidates":[]}`, response.Body.String())
+	})
+
+	t.Run("when the limit is invalid, it should return a bad request", func(t *testing.T) {
+		service := new(mocks.ServiceMock)
+
+		response := serve(service, http.MethodPost, "/contacts/match?limit=0", "application/json", `{"first_name":"John"}`)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, response.Body.String(), server.InvalidLimitError)
+		service.AssertNotCalled(t, "Match", mock.Anything, mock.Anything)
+	})
+
+	t.Run("when the contact is not valid JSON, it should return a bad request", func(t *testing.T) {
+		response := serve(new(mocks.ServiceMock), http.MethodPost, "/contacts/match", "application/json", `{"first_name":`)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("when the contact is empty, it should return a bad request", func(t *testing.T) {
+		service := new(mocks.ServiceMock)
+		service.On("Match", mock.Anything, contact.Contact{}).Return([]contact.Candidate(nil), errors.New(contact.EmptyContactError))
+
+		response := serve(service, http.MethodPost, "/contacts/match", "application/json", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.JSONEq(t, `{"error":"contact has no values to match"}`, response.Body.String())
+	})
+
+	t.Run("when the service fails, it should return an internal error", func(t *testing.T) {
+		service := new(mocks.ServiceMock)
+		service.On("Match", mock.Anything, lookup).Return([]contact.Candidate(nil), errors.New("read error"))
+
+		response := serve(service, http.MethodPost, "/contacts/match", "application/json",
+			`{"first_name":"John","last_name":"Doe","email":"john@example.com"}`)
+
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+	})
+
+	t.Run("when the method is not allowed, it should not match", func(t *testing.T) {
+		response := serve(new(mocks.ServiceMock), http.MethodGet, "/contacts/match", "", "")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	})
+
+	t.Run("when a CSV batch is uploaded, it should return its matches and duplicates", func(t *testing.T) {
+		csv := "contactID,name,name1,email,postalZip,address\n" +
+			"1,John,Doe,john@example.com,12345,1 Main St\n" +
+			"2,Jon,Doe,john@example.com,12345,1 Main St\n" +
+			"3,Jane,Smith,jane@example.com,54321,9 Oak Ave\n"
+
+		response := serve(new(mocks.ServiceMock), http.MethodPost, "/contacts/batch", "text/csv; charset=utf-8", csv)
+
+		var body server.BatchResponse
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+		assert.Len(t, body.Matches, 2)
+		assert.Equal(t, "1", body.Matches[0].ContactIDSource)
+		assert.Equal(t, "2", body.Matches[0].ContactIDMatch)
+		assert.NotNil(t, body.Duplicates)
+		assert.Len(t, body.Clusters, 3)
+		assert.Len(t, body.Quality, 5)
+		assert.Equal(t, contact.FieldQuality{Field: contact.EmailField, Values: 3, Valid: 3}, body.Quality[2])
+	})
+
+	t.Run("when a JSON batch is uploaded, it should return the same matches as a CSV one", func(t *testing.T) {
+		batch := `[
+			{"contact_id":"1","first_name":"John","last_name":"Doe","email":"john@example.com","zip_code":"12345","address":"1 Main St"},
+			{"contact_id":"2","first_name":"Jon","last_name":"Doe","email":"john@example.com","zip_code":"12345","address":"1 Main St"}
+		]`
+
+		response := serve(new(mocks.ServiceMock), http.MethodPost, "/contacts/batch", "application/json", batch)
+
+		var body server.BatchResponse
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+		assert.Len(t, body.Matches, 2)
+	})
+
+	t.Run("when an NDJSON batch is uploaded, it should read a contact per line", func(t *testing.T) {
+		batch := `{"contact_id":1,"first_name":"John","last_name":"Doe","email":"john@example.com","zip_code":12345,"address":"1 Main St"}
+{"contact_id":2,"first_name":"Jon","last_name":"Doe","email":"john@example.com","zip_code":12345,"address":"1 Main St"}
+`
+
+		response := serve(new(mocks.ServiceMock), http.MethodPost, "/contacts/batch", "application/x-ndjson", batch)
+
+		var body server.BatchResponse
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+		assert.Len(t, body.Matches, 2)
+		assert.Equal(t, "1", body.Matches[0].ContactIDSource)
+	})
+
+	t.Run("when a JSON batch uses other keys, it should read them with the column mapping", func(t *testing.T) {
+		batch := `[
+			{"id":"1","Given Name":"John","Family Name":"Doe","email":"john@example.com","zip":"12345","address":"1 Main St"},
+			{"id":"2","Given Name":"Jon","Family Name":"Doe","email":"john@example.com","zip":"12345","address":"1 Main St"}
+		]`
+		columns := contact.DefaultColumnMapping()
+		columns[contact.FirstNameField] = []string{"Given Name"}
+		columns[contact.LastNameField] = []string{"Family Name"}
+
+		request := httptest.NewRequest(http.MethodPost, "/contacts/batch", strings.NewReader(batch))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+		server.NewHandler(logger, new(mocks.ServiceMock), contact.DefaultConfig(), columns).ServeHTTP(response, request)
+
+		var body server.BatchResponse
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+		assert.Len(t, body.Matches, 2)
+		assert.Len(t, body.Merged, 1)
+		assert.Equal(t, "Doe", body.Merged[0].LastName)
+	})
+
+	t.Run("when a JSON batch misses a column, it should return a bad request like a CSV one", func(t *testing.T) {
+		response := serve(new(mocks.ServiceMock), http.MethodPost, "/contacts/batch", "application/json", `[{"contact_id":"1","first_name":"John"}]`)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, response.Body.String(), contact.MissingColumnError)
+	})
+
+	t.Run("when the CSV batch misses a column, it should return a bad request", func(t *testing.T) {
+		response := serve(new(mocks.ServiceMock), http.MethodPost, "/contacts/batch", "text/csv", "contactID,name\n1,John\n")
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, response.Body.String(), contact.MissingColumnError)
+	})
+
+	t.Run("when the batch has another content type, it should return unsupported media type", func(t *testing.T) {
+		response := serve(new(mocks.ServiceMock), http.MethodPost, "/contacts/batch", "text/plain", "contacts")
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, response.Code)
+		assert.Contains(t, response.Body.String(), server.UnsupportedContentTypeError)
+	})
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"iter"
+	"slices"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+)
+
+// memoryRepository serves the uploaded contacts to a batch evaluation and keeps its outputs to answer the request
+type memoryRepository struct {
+	contacts   []contact.Contact
+	matches    []contact.ProcessOutput
+	duplicates []contact.Contact
+	clusters   []contact.ClusterAssignment
+	merged     []contact.Contact
+	mapping    []contact.ContactMapping
+	quality    []contact.FieldQuality
+}
+
+func (m *memoryRepository) GetContactData(context.Context) ([]contact.Contact, error) {
+	return m.contacts, nil
+}
+
+func (m *memoryRepository) GetReferenceData(context.Context) ([]contact.Contact, error) {
+	return nil, nil
+}
+
+func (m *memoryRepository) WriteContactData(_ context.Context, data iter.Seq[contact.ProcessOutput]) error {
+	m.matches = slices.Collect(data)
+	return nil
+}
+
+func (m *memoryRepository) WriteDuplicateContacts(_ context.Context, duplicates iter.Seq[contact.Contact]) error {
+	m.duplicates = slices.Collect(duplicates)
+	return nil
+}
+
+func (m *memoryRepository) WriteClusters(_ context.Context, assignments iter.Seq[contact.ClusterAssignment]) error {
+	m.clusters = slices.Collect(assignments)
+	return nil
+}
+
+func (m *memoryRepository) WriteMergedContacts(_ context.Context, contacts iter.Seq[contact.Contact]) error {
+	m.merged = slices.Collect(contacts)
+	return nil
+}
+
+func (m *memoryRepository) WriteContactMapping(_ context.Context, mapping iter.Seq[contact.ContactMapping]) error {
+	m.mapping = slices.Collect(mapping)
+	return nil
+}
+
+func (m *memoryRepository) WriteExplanations(context.Context, iter.Seq[contact.ProcessOutput]) error {
+	// The explanations are part of the matches
+	return nil
+}
+
+func (m *memoryRepository) WriteQualityReport(_ context.Context, report []contact.FieldQuality) error {
+	m.quality = report
+	return nil
+}
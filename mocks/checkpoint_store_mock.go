@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/stretchr/testify/mock"
+)
+
+type CheckpointStoreMock struct {
+	mock.Mock
+}
+
+func (m *CheckpointStoreMock) InputChecksum(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *CheckpointStoreMock) LoadCheckpoint(ctx context.Context) (contact.Checkpoint, bool, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(contact.Checkpoint), args.Bool(1), args.Error(2)
+}
+
+func (m *CheckpointStoreMock) SaveCheckpoint(ctx context.Context, checkpoint contact.Checkpoint) error {
+	args := m.Called(ctx, checkpoint)
+	return args.Error(0)
+}
+
+func (m *CheckpointStoreMock) RemoveCheckpoint(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -1,25 +1,95 @@
 package mocks
 
 import (
+	"context"
+	"iter"
+	"slices"
+
 	"github.com/sebastianreh/compass-code-assessment/internal/contact"
 	"github.com/stretchr/testify/mock"
 )
 
+// RepositoryMock collects the written values, so the expectations compare slices
 type RepositoryMock struct {
 	mock.Mock
 }
 
-func (m *RepositoryMock) GetContactData() ([]contact.Contact, error) {
-	args := m.Called()
+func (m *RepositoryMock) GetContactData(ctx context.Context) ([]contact.Contact, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]contact.Contact), args.Error(1)
+}
+
+func (m *RepositoryMock) GetReferenceData(ctx context.Context) ([]contact.Contact, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]contact.Contact), args.Error(1)
 }
 
-func (m *RepositoryMock) WriteContactData(data []contact.ProcessOutput) error {
-	args := m.Called(data)
+func (m *RepositoryMock) WriteContactData(ctx context.Context, data iter.Seq[contact.ProcessOutput]) error {
+	args := m.Called(ctx, slices.Collect(data))
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) WriteDuplicateContacts(ctx context.Context, duplicate iter.Seq[contact.Contact]) error {
+	args := m.Called(ctx, slices.Collect(duplicate))
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) WriteClusters(ctx context.Context, assignments iter.Seq[contact.ClusterAssignment]) error {
+	args := m.Called(ctx, slices.Collect(assignments))
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) WriteMergedContacts(ctx context.Context, contacts iter.Seq[contact.Contact]) error {
+	args := m.Called(ctx, slices.Collect(contacts))
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) WriteContactMapping(ctx context.Context, mapping iter.Seq[contact.ContactMapping]) error {
+	args := m.Called(ctx, slices.Collect(mapping))
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) WriteExplanations(ctx context.Context, data iter.Seq[contact.ProcessOutput]) error {
+	args := m.Called(ctx, slices.Collect(data))
 	return args.Error(0)
 }
 
-func (m *RepositoryMock) WriteDuplicateContacts(duplicate []contact.Contact) error {
-	args := m.Called(duplicate)
+func (m *RepositoryMock) WriteQualityReport(ctx context.Context, report []contact.FieldQuality) error {
+	args := m.Called(ctx, report)
+	return args.Error(0)
+}
+
+// TransactionalRepositoryMock is a RepositoryMock also implementing contact.Transactional
+type TransactionalRepositoryMock struct {
+	RepositoryMock
+}
+
+func (m *TransactionalRepositoryMock) Begin(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *TransactionalRepositoryMock) Commit(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *TransactionalRepositoryMock) Rollback(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// RejectingRepositoryMock is a RepositoryMock also implementing contact.Rejecting
+type RejectingRepositoryMock struct {
+	RepositoryMock
+}
+
+func (m *RejectingRepositoryMock) Rejects() []contact.Reject {
+	args := m.Called()
+	return args.Get(0).([]contact.Reject)
+}
+
+func (m *RejectingRepositoryMock) WriteRejects(ctx context.Context, rejects []contact.Reject) error {
+	args := m.Called(ctx, rejects)
 	return args.Error(0)
 }
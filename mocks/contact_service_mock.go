@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+	"iter"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/stretchr/testify/mock"
+)
+
+type ServiceMock struct {
+	mock.Mock
+}
+
+func (m *ServiceMock) Evaluate(ctx context.Context) (iter.Seq[contact.ProcessOutput], error) {
+	args := m.Called(ctx)
+	return args.Get(0).(iter.Seq[contact.ProcessOutput]), args.Error(1)
+}
+
+func (m *ServiceMock) Match(ctx context.Context, lookup contact.Contact) ([]contact.Candidate, error) {
+	args := m.Called(ctx, lookup)
+	return args.Get(0).([]contact.Candidate), args.Error(1)
+}
@@ -1,6 +1,11 @@
 package mocks
 
 import (
+	"context"
+	"encoding/csv"
+	"iter"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -8,12 +13,63 @@ type CsvMock struct {
 	mock.Mock
 }
 
-func (m *CsvMock) ReadCSV(filePath string) ([][]string, error) {
-	args := m.Called(filePath)
+func (m *CsvMock) ReadCSV(ctx context.Context, filePath string) ([][]string, error) {
+	args := m.Called(ctx, filePath)
 	return args.Get(0).([][]string), args.Error(1)
 }
 
-func (m *CsvMock) WriteCSV(filePath string, header []string, data [][]string) error {
-	args := m.Called(filePath, header, data)
+func (m *CsvMock) WriteCSV(ctx context.Context, filePath string, header []string, data [][]string) error {
+	args := m.Called(ctx, filePath, header, data)
 	return args.Error(0)
 }
+
+func (m *CsvMock) StreamCSV(ctx context.Context, filePath string) iter.Seq2[[]string, error] {
+	args := m.Called(ctx, filePath)
+	return args.Get(0).(iter.Seq2[[]string, error])
+}
+
+func (m *CsvMock) NewCSVWriter(ctx context.Context, filePath string, header []string) (pkg.CSVWriter, error) {
+	args := m.Called(ctx, filePath, header)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(pkg.CSVWriter), args.Error(1)
+}
+
+type CsvWriterMock struct {
+	mock.Mock
+}
+
+func (m *CsvWriterMock) Write(record []string) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *CsvWriterMock) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *CsvWriterMock) Abort() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// CSVRows streams the records as CSVConnector.StreamCSV does, a record with a different number of fields than the
+// first one is yielded as a *pkg.RecordError on its line, and err is yielded after them when it is not nil
+func CSVRows(records [][]string, err error) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		for i, record := range records {
+			var recordErr error
+			if len(record) != len(records[0]) {
+				record, recordErr = nil, &pkg.RecordError{Line: i + 1, Record: record, Err: csv.ErrFieldCount}
+			}
+			if !yield(record, recordErr) {
+				return
+			}
+		}
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
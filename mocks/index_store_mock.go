@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/sebastianreh/compass-code-assessment/internal/contact"
+	"github.com/stretchr/testify/mock"
+)
+
+type IndexStoreMock struct {
+	mock.Mock
+}
+
+func (m *IndexStoreMock) LoadIndex(ctx context.Context) (contact.MatchIndex, bool, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(contact.MatchIndex), args.Bool(1), args.Error(2)
+}
+
+func (m *IndexStoreMock) SaveIndex(ctx context.Context, index contact.MatchIndex) error {
+	args := m.Called(ctx, index)
+	return args.Error(0)
+}
@@ -0,0 +1,107 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/mock"
+)
+
+type PostgresDBMock struct {
+	mock.Mock
+}
+
+func (m *PostgresDBMock) BeginTx(ctx context.Context, options pgx.TxOptions) (pgx.Tx, error) {
+	args := m.Called(ctx, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(pgx.Tx), args.Error(1)
+}
+
+// PgxTxMock mocks the statements the Postgres repository runs, the embedded pgx.Tx is nil so any other method panics
+type PgxTxMock struct {
+	mock.Mock
+	pgx.Tx
+}
+
+func (m *PgxTxMock) Commit(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *PgxTxMock) Rollback(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *PgxTxMock) Exec(ctx context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	args := m.Called(ctx, sql)
+	return pgconn.CommandTag{}, args.Error(0)
+}
+
+func (m *PgxTxMock) Query(ctx context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	args := m.Called(ctx, sql)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(pgx.Rows), args.Error(1)
+}
+
+// CopyFrom reads every row of the source, so the expectations get the copied rows instead of the source
+func (m *PgxTxMock) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	var rows [][]any
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, values)
+	}
+
+	args := m.Called(ctx, tableName, columnNames, rows)
+	return int64(len(rows)), args.Error(0)
+}
+
+// PgxRows returns the rows of a FETCH with the given columns, every value is sent as text like the simple protocol
+// does and a nil value is NULL
+func PgxRows(columns []string, records [][]any) pgx.Rows {
+	return &pgxRows{columns: columns, records: records, current: -1}
+}
+
+type pgxRows struct {
+	pgx.Rows
+	columns []string
+	records [][]any
+	current int
+}
+
+func (r *pgxRows) Close() {}
+
+func (r *pgxRows) Err() error {
+	return nil
+}
+
+func (r *pgxRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, column := range r.columns {
+		fields[i] = pgconn.FieldDescription{Name: column}
+	}
+	return fields
+}
+
+func (r *pgxRows) Next() bool {
+	r.current++
+	return r.current < len(r.records)
+}
+
+func (r *pgxRows) RawValues() [][]byte {
+	values := make([][]byte, len(r.records[r.current]))
+	for i, value := range r.records[r.current] {
+		if value != nil {
+			values[i] = []byte(value.(string))
+		}
+	}
+	return values
+}
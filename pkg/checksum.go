@@ -0,0 +1,30 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FileChecksum returns the hex encoded SHA-256 of the file contents
+func FileChecksum(ctx context.Context, filePath string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("error reading file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
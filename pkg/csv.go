@@ -1,14 +1,28 @@
 package pkg
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"os"
 )
 
 type CSVConnector interface {
-	ReadCSV(filePath string) ([][]string, error)
-	WriteCSV(filePath string, header []string, data [][]string) error
+	ReadCSV(ctx context.Context, filePath string) ([][]string, error)
+	WriteCSV(ctx context.Context, filePath string, header []string, data [][]string) error
+	StreamCSV(ctx context.Context, filePath string) iter.Seq2[[]string, error]
+	NewCSVWriter(ctx context.Context, filePath string, header []string) (CSVWriter, error)
+}
+
+// CSVWriter writes the records one at a time to a temporary file.
+// Close replaces the destination file with it, Abort discards it leaving the destination untouched.
+type CSVWriter interface {
+	Write(record []string) error
+	Close() error
+	Abort() error
 }
 
 type csvConnector struct{}
@@ -17,8 +31,12 @@ func NewCSVConnector() CSVConnector {
 	return &csvConnector{}
 }
 
-func (csvConnector) ReadCSV(filePath string) ([][]string, error) {
+func (csvConnector) ReadCSV(ctx context.Context, filePath string) ([][]string, error) {
 	output := make([][]string, 0)
+	if err := ctx.Err(); err != nil {
+		return output, err
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return output, fmt.Errorf("error opening file: %w", err)
@@ -34,27 +52,147 @@ func (csvConnector) ReadCSV(filePath string) ([][]string, error) {
 	return records, nil
 }
 
-func (csvConnector) WriteCSV(filePath string, header []string, data [][]string) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("error creating file: %w", err)
+// StreamCSV yields the records one at a time, the header included. A record that can't be parsed or has a different
+// number of fields than the header is yielded as a *RecordError with a nil record, and the stream goes on.
+// Any other failure or the context being done is yielded as the last element with a nil record.
+func (csvConnector) StreamCSV(ctx context.Context, filePath string) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		file, err := os.Open(filePath)
+		if err != nil {
+			yield(nil, fmt.Errorf("error opening file: %w", err))
+			return
+		}
+		defer file.Close()
+
+		for record, err := range StreamCSVReader(ctx, file) {
+			if !yield(record, err) {
+				return
+			}
+		}
 	}
-	defer file.Close()
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// StreamCSVReader yields the records read from r the same way as StreamCSV, for uploads and other streams
+func StreamCSVReader(ctx context.Context, r io.Reader) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		// Every record must have as many fields as the header
+		reader := csv.NewReader(r)
 
-	if len(header) > 0 {
-		if err := writer.Write(header); err != nil {
-			return fmt.Errorf("error writing header: %w", err)
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			// Only a record with another number of fields was read whole
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				if !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+					record = nil
+				}
+				if !yield(nil, &RecordError{Line: parseErr.StartLine, Record: record, Err: parseErr.Err}) {
+					return
+				}
+				continue
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("error reading CSV file: %w", err))
+				return
+			}
+
+			if !yield(record, nil) {
+				return
+			}
 		}
 	}
+}
+
+func (c csvConnector) WriteCSV(ctx context.Context, filePath string, header []string, data [][]string) error {
+	writer, err := c.NewCSVWriter(ctx, filePath, header)
+	if err != nil {
+		return err
+	}
 
 	for _, record := range data {
 		if err := writer.Write(record); err != nil {
-			return fmt.Errorf("error writing record: %w", err)
+			writer.Abort()
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+type csvWriter struct {
+	ctx      context.Context
+	file     *os.File
+	writer   *csv.Writer
+	filePath string
+}
+
+// NewCSVWriter creates a temporary file next to the destination and writes the header, an empty header is skipped
+func (csvConnector) NewCSVWriter(ctx context.Context, filePath string, header []string) (CSVWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	file, err := createTempFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &csvWriter{
+		ctx:      ctx,
+		file:     file,
+		writer:   csv.NewWriter(file),
+		filePath: filePath,
+	}
+
+	if len(header) > 0 {
+		if err := writer.writer.Write(header); err != nil {
+			writer.Abort()
+			return nil, fmt.Errorf("error writing header: %w", err)
 		}
 	}
 
+	return writer, nil
+}
+
+func (w *csvWriter) Write(record []string) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := w.writer.Write(record); err != nil {
+		return fmt.Errorf("error writing record: %w", err)
+	}
+	return nil
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		w.Abort()
+		return err
+	}
+
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.Abort()
+		return fmt.Errorf("error writing record: %w", err)
+	}
+
+	return commitFile(w.file, w.filePath)
+}
+
+func (w *csvWriter) Abort() error {
+	w.file.Close()
+	if err := os.Remove(w.file.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing temporary file: %w", err)
+	}
 	return nil
 }
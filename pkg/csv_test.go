@@ -1,10 +1,13 @@
 package pkg_test
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"github.com/sebastianreh/compass-code-assessment/pkg"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,7 +21,7 @@ func TestCSVConnector_ReadCSV(t *testing.T) {
 		tempFile := createTempCSVFile(t, content)
 		defer os.Remove(tempFile.Name())
 
-		data, err := connector.ReadCSV(tempFile.Name())
+		data, err := connector.ReadCSV(context.Background(), tempFile.Name())
 
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(data))
@@ -27,7 +30,7 @@ func TestCSVConnector_ReadCSV(t *testing.T) {
 	})
 
 	t.Run("when the file does not exist, it should return an error", func(t *testing.T) {
-		_, err := connector.ReadCSV("non_existent_file.csv")
+		_, err := connector.ReadCSV(context.Background(), "non_existent_file.csv")
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "error opening file")
 	})
@@ -36,7 +39,7 @@ func TestCSVConnector_ReadCSV(t *testing.T) {
 		tempFile := createTempCSVFile(t, "header1,header2\nvalue1")
 		defer os.Remove(tempFile.Name())
 
-		_, err := connector.ReadCSV(tempFile.Name())
+		_, err := connector.ReadCSV(context.Background(), tempFile.Name())
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "error reading CSV file")
 	})
@@ -55,7 +58,7 @@ func TestCSVConnector_WriteCSV(t *testing.T) {
 			{"value1", "value2"},
 		}
 
-		err = connector.WriteCSV(tempFile.Name(), header, data)
+		err = connector.WriteCSV(context.Background(), tempFile.Name(), header, data)
 		assert.Nil(t, err)
 
 		fileContent := readCSVFile(t, tempFile.Name())
@@ -63,7 +66,7 @@ func TestCSVConnector_WriteCSV(t *testing.T) {
 	})
 
 	t.Run("when the file cannot be created, it should return an error", func(t *testing.T) {
-		err := connector.WriteCSV("/invalid_path/output.csv", []string{"header"}, [][]string{{"value1"}})
+		err := connector.WriteCSV(context.Background(), "/invalid_path/output.csv", []string{"header"}, [][]string{{"value1"}})
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "error creating file")
 	})
@@ -77,7 +80,7 @@ func TestCSVConnector_WriteCSV(t *testing.T) {
 			{"value1", "value2"},
 		}
 
-		err = connector.WriteCSV(tempFile.Name(), nil, data)
+		err = connector.WriteCSV(context.Background(), tempFile.Name(), nil, data)
 		assert.Nil(t, err)
 
 		fileContent := readCSVFile(t, tempFile.Name())
@@ -85,6 +88,162 @@ func TestCSVConnector_WriteCSV(t *testing.T) {
 	})
 }
 
+func TestCSVConnector_StreamCSV(t *testing.T) {
+	connector := pkg.NewCSVConnector()
+
+	t.Run("when streaming a CSV file successfully, it should yield every record", func(t *testing.T) {
+		tempFile := createTempCSVFile(t, "header1,header2\nvalue1,value2\n\"multi\nline\",value4\n")
+		defer os.Remove(tempFile.Name())
+
+		var records [][]string
+		for record, err := range connector.StreamCSV(context.Background(), tempFile.Name()) {
+			assert.Nil(t, err)
+			records = append(records, record)
+		}
+
+		assert.Equal(t, [][]string{{"header1", "header2"}, {"value1", "value2"}, {"multi\nline", "value4"}}, records)
+	})
+
+	t.Run("when a record can't be parsed or has another number of fields, it should yield a record error and go on", func(t *testing.T) {
+		tempFile := createTempCSVFile(t, "header1,header2\n\"multi\nline\",value2\n2,Jo\"e\nvalue3\nvalue4,value5\n")
+		defer os.Remove(tempFile.Name())
+
+		var records [][]string
+		var recordErrs []*pkg.RecordError
+		for record, err := range connector.StreamCSV(context.Background(), tempFile.Name()) {
+			var recordErr *pkg.RecordError
+			if errors.As(err, &recordErr) {
+				recordErrs = append(recordErrs, recordErr)
+				continue
+			}
+			assert.Nil(t, err)
+			records = append(records, record)
+		}
+
+		assert.Equal(t, [][]string{{"header1", "header2"}, {"multi\nline", "value2"}, {"value4", "value5"}}, records)
+		assert.Equal(t, 2, len(recordErrs))
+		assert.Equal(t, 4, recordErrs[0].Line)
+		assert.ErrorIs(t, recordErrs[0], csv.ErrBareQuote)
+		assert.Equal(t, &pkg.RecordError{Line: 5, Record: []string{"value3"}, Err: csv.ErrFieldCount}, recordErrs[1])
+	})
+
+	t.Run("when the loop stops early, it should stop reading", func(t *testing.T) {
+		tempFile := createTempCSVFile(t, "header1,header2\nvalue1,value2\n")
+		defer os.Remove(tempFile.Name())
+
+		var records [][]string
+		for record := range connector.StreamCSV(context.Background(), tempFile.Name()) {
+			records = append(records, record)
+			break
+		}
+
+		assert.Equal(t, [][]string{{"header1", "header2"}}, records)
+	})
+
+	t.Run("when the context is cancelled, it should yield the context error", func(t *testing.T) {
+		tempFile := createTempCSVFile(t, "header1,header2\nvalue1,value2\n")
+		defer os.Remove(tempFile.Name())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var records [][]string
+		var lastErr error
+		for record, err := range connector.StreamCSV(ctx, tempFile.Name()) {
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			records = append(records, record)
+			cancel()
+		}
+
+		assert.Equal(t, [][]string{{"header1", "header2"}}, records)
+		assert.ErrorIs(t, lastErr, context.Canceled)
+	})
+
+	t.Run("when the file does not exist, it should yield an error", func(t *testing.T) {
+		var errs []error
+		for _, err := range connector.StreamCSV(context.Background(), "non_existent_file.csv") {
+			errs = append(errs, err)
+		}
+
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "error opening file")
+	})
+
+	t.Run("when a quoted field is never closed, it should yield the records before the error", func(t *testing.T) {
+		tempFile := createTempCSVFile(t, "header1,header2\n\"value1,value2\n")
+		defer os.Remove(tempFile.Name())
+
+		var records [][]string
+		var lastErr error
+		for record, err := range connector.StreamCSV(context.Background(), tempFile.Name()) {
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			records = append(records, record)
+		}
+
+		assert.Equal(t, [][]string{{"header1", "header2"}}, records)
+		assert.NotNil(t, lastErr)
+		assert.ErrorIs(t, lastErr, csv.ErrQuote)
+	})
+}
+
+func TestCSVConnector_NewCSVWriter(t *testing.T) {
+	connector := pkg.NewCSVConnector()
+
+	t.Run("when writing records one at a time, it should write them after the header", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.csv")
+
+		writer, err := connector.NewCSVWriter(context.Background(), filePath, []string{"header1", "header2"})
+		assert.Nil(t, err)
+		assert.Nil(t, writer.Write([]string{"value1", "value2"}))
+		assert.Nil(t, writer.Write([]string{"value3", "value4"}))
+		assert.Nil(t, writer.Close())
+
+		fileContent := readCSVFile(t, filePath)
+		assert.Equal(t, [][]string{{"header1", "header2"}, {"value1", "value2"}, {"value3", "value4"}}, fileContent)
+	})
+
+	t.Run("when the writer is aborted, it should leave the existing file untouched", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.csv")
+		assert.Nil(t, os.WriteFile(filePath, []byte("previous\n"), 0o644))
+
+		writer, err := connector.NewCSVWriter(context.Background(), filePath, []string{"header1"})
+		assert.Nil(t, err)
+		assert.Nil(t, writer.Write([]string{"value1"}))
+		assert.Nil(t, writer.Abort())
+
+		assert.Equal(t, [][]string{{"previous"}}, readCSVFile(t, filePath))
+		entries, err := os.ReadDir(filepath.Dir(filePath))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(entries))
+	})
+
+	t.Run("when the context is cancelled before closing, it should return an error and leave the existing file untouched", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.csv")
+		assert.Nil(t, os.WriteFile(filePath, []byte("previous\n"), 0o644))
+		ctx, cancel := context.WithCancel(context.Background())
+
+		writer, err := connector.NewCSVWriter(ctx, filePath, []string{"header1"})
+		assert.Nil(t, err)
+		assert.Nil(t, writer.Write([]string{"value1"}))
+		cancel()
+
+		assert.ErrorIs(t, writer.Write([]string{"value2"}), context.Canceled)
+		assert.ErrorIs(t, writer.Close(), context.Canceled)
+		assert.Equal(t, [][]string{{"previous"}}, readCSVFile(t, filePath))
+	})
+
+	t.Run("when the file cannot be created, it should return an error", func(t *testing.T) {
+		_, err := connector.NewCSVWriter(context.Background(), "/invalid_path/output.csv", []string{"header"})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "error creating file")
+	})
+}
+
 // Helper function to create a temporary CSV
 func createTempCSVFile(t *testing.T, content string) *os.File {
 	tempFile, err := ioutil.TempFile("", "test_csv_*.csv")
@@ -0,0 +1,581 @@
+package pkg
+
+import "strings"
+
+const doubleMetaphoneMaxLength = 4
+
+// DoubleMetaphone returns the primary and alternate Double Metaphone keys of the given word.
+// The alternate key covers other plausible pronunciations, e.g. "Schmidt" -> "XMT", "SMT" and
+// "Smith" -> "SM0", "XMT", so two words are a phonetic match when any of their keys are equal.
+func DoubleMetaphone(word string) (string, string) {
+	value := strings.ToUpper(strings.TrimSpace(word))
+	if value == "" {
+		return "", ""
+	}
+
+	m := &doubleMetaphone{
+		value:         value,
+		length:        len(value),
+		slavoGermanic: isSlavoGermanic(value),
+	}
+	m.encode()
+
+	return m.primary.String(), m.alternate.String()
+}
+
+type doubleMetaphone struct {
+	value         string
+	length        int
+	slavoGermanic bool
+	primary       strings.Builder
+	alternate     strings.Builder
+}
+
+func (m *doubleMetaphone) encode() {
+	index := 0
+	if m.contains(0, 2, "GN", "KN", "PN", "WR", "PS") {
+		index = 1
+	}
+
+	for !m.complete() && index < m.length {
+		switch m.charAt(index) {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if index == 0 {
+				m.add("A")
+			}
+			index++
+		case 'B':
+			m.add("P")
+			index = m.skipIf(index, 'B')
+		case 'C':
+			index = m.handleC(index)
+		case 'D':
+			index = m.handleD(index)
+		case 'F':
+			m.add("F")
+			index = m.skipIf(index, 'F')
+		case 'G':
+			index = m.handleG(index)
+		case 'H':
+			index = m.handleH(index)
+		case 'J':
+			index = m.handleJ(index)
+		case 'K':
+			m.add("K")
+			index = m.skipIf(index, 'K')
+		case 'L':
+			index = m.handleL(index)
+		case 'M':
+			m.add("M")
+			if m.conditionM0(index) {
+				index += 2
+			} else {
+				index++
+			}
+		case 'N':
+			m.add("N")
+			index = m.skipIf(index, 'N')
+		case 'P':
+			index = m.handleP(index)
+		case 'Q':
+			m.add("K")
+			index = m.skipIf(index, 'Q')
+		case 'R':
+			index = m.handleR(index)
+		case 'S':
+			index = m.handleS(index)
+		case 'T':
+			index = m.handleT(index)
+		case 'V':
+			m.add("F")
+			index = m.skipIf(index, 'V')
+		case 'W':
+			index = m.handleW(index)
+		case 'X':
+			index = m.handleX(index)
+		case 'Z':
+			index = m.handleZ(index)
+		default:
+			index++
+		}
+	}
+}
+
+func (m *doubleMetaphone) handleC(index int) int {
+	switch {
+	case m.conditionC0(index):
+		m.add("K")
+		return index + 2
+	case index == 0 && m.contains(index, 6, "CAESAR"):
+		m.add("S")
+		return index + 2
+	case m.contains(index, 2, "CH"):
+		return m.handleCH(index)
+	case m.contains(index, 2, "CZ") && !m.contains(index-2, 4, "WICZ"):
+		m.addBoth("S", "X")
+		return index + 2
+	case m.contains(index+1, 3, "CIA"):
+		m.add("X")
+		return index + 3
+	case m.contains(index, 2, "CC") && !(index == 1 && m.charAt(0) == 'M'):
+		return m.handleCC(index)
+	case m.contains(index, 2, "CK", "CG", "CQ"):
+		m.add("K")
+		return index + 2
+	case m.contains(index, 2, "CI", "CE", "CY"):
+		if m.contains(index, 3, "CIO", "CIE", "CIA") {
+			m.addBoth("S", "X")
+		} else {
+			m.add("S")
+		}
+		return index + 2
+	}
+
+	m.add("K")
+	switch {
+	case m.contains(index+1, 2, " C", " Q", " G"):
+		return index + 3
+	case m.contains(index+1, 1, "C", "K", "Q") && !m.contains(index+1, 2, "CE", "CI"):
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleCC(index int) int {
+	if m.contains(index+2, 1, "I", "E", "H") && !m.contains(index+2, 2, "HU") {
+		if (index == 1 && m.charAt(index-1) == 'A') || m.contains(index-1, 5, "UCCEE", "UCCES") {
+			m.add("KS")
+		} else {
+			m.add("X")
+		}
+		return index + 3
+	}
+
+	m.add("K")
+	return index + 2
+}
+
+func (m *doubleMetaphone) handleCH(index int) int {
+	switch {
+	case index > 0 && m.contains(index, 4, "CHAE"):
+		m.addBoth("K", "X")
+	case m.conditionCH0(index), m.conditionCH1(index):
+		m.add("K")
+	case index > 0:
+		if m.contains(0, 2, "MC") {
+			m.add("K")
+		} else {
+			m.addBoth("X", "K")
+		}
+	default:
+		m.add("X")
+	}
+	return index + 2
+}
+
+func (m *doubleMetaphone) handleD(index int) int {
+	switch {
+	case m.contains(index, 2, "DG"):
+		if m.contains(index+2, 1, "I", "E", "Y") {
+			m.add("J")
+			return index + 3
+		}
+		m.add("TK")
+		return index + 2
+	case m.contains(index, 2, "DT", "DD"):
+		m.add("T")
+		return index + 2
+	}
+
+	m.add("T")
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleG(index int) int {
+	next := m.charAt(index + 1)
+
+	switch {
+	case next == 'H':
+		return m.handleGH(index)
+	case next == 'N':
+		switch {
+		case index == 1 && m.isVowelAt(0) && !m.slavoGermanic:
+			m.addBoth("KN", "N")
+		case !m.contains(index+2, 2, "EY") && next != 'Y' && !m.slavoGermanic:
+			m.addBoth("N", "KN")
+		default:
+			m.add("KN")
+		}
+		return index + 2
+	case m.contains(index+1, 2, "LI") && !m.slavoGermanic:
+		m.addBoth("KL", "L")
+		return index + 2
+	case index == 0 && (next == 'Y' || m.contains(index+1, 2, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		m.addBoth("K", "J")
+		return index + 2
+	case (m.contains(index+1, 2, "ER") || next == 'Y') &&
+		!m.contains(0, 6, "DANGER", "RANGER", "MANGER") &&
+		!m.contains(index-1, 1, "E", "I") &&
+		!m.contains(index-1, 3, "RGY", "OGY"):
+		m.addBoth("K", "J")
+		return index + 2
+	case m.contains(index+1, 1, "E", "I", "Y") || m.contains(index-1, 4, "AGGI", "OGGI"):
+		switch {
+		case m.contains(0, 4, "VAN ", "VON ") || m.contains(0, 3, "SCH") || m.contains(index+1, 2, "ET"):
+			m.add("K")
+		case m.contains(index+1, 3, "IER"):
+			m.add("J")
+		default:
+			m.addBoth("J", "K")
+		}
+		return index + 2
+	case next == 'G':
+		m.add("K")
+		return index + 2
+	}
+
+	m.add("K")
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleGH(index int) int {
+	switch {
+	case index > 0 && !m.isVowelAt(index-1):
+		m.add("K")
+	case index == 0:
+		if m.charAt(index+2) == 'I' {
+			m.add("J")
+		} else {
+			m.add("K")
+		}
+	case (index > 1 && m.contains(index-2, 1, "B", "H", "D")) ||
+		(index > 2 && m.contains(index-3, 1, "B", "H", "D")) ||
+		(index > 3 && m.contains(index-4, 1, "B", "H")):
+		// Parker's rule, e.g. "hugh", "bough" and "broughton" have a silent GH
+	case index > 2 && m.charAt(index-1) == 'U' && m.contains(index-3, 1, "C", "G", "L", "R", "T"):
+		m.add("F")
+	case index > 0 && m.charAt(index-1) != 'I':
+		m.add("K")
+	}
+	return index + 2
+}
+
+func (m *doubleMetaphone) handleH(index int) int {
+	if (index == 0 || m.isVowelAt(index-1)) && m.isVowelAt(index+1) {
+		m.add("H")
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleJ(index int) int {
+	if m.contains(index, 4, "JOSE") || m.contains(0, 4, "SAN ") {
+		if (index == 0 && m.charAt(index+4) == ' ') || m.length == 4 || m.contains(0, 4, "SAN ") {
+			m.add("H")
+		} else {
+			m.addBoth("J", "H")
+		}
+		return index + 1
+	}
+
+	switch {
+	case index == 0:
+		m.addBoth("J", "A")
+	case m.isVowelAt(index-1) && !m.slavoGermanic && (m.charAt(index+1) == 'A' || m.charAt(index+1) == 'O'):
+		m.addBoth("J", "H")
+	case index == m.length-1:
+		m.addBoth("J", "")
+	case !m.contains(index+1, 1, "L", "T", "K", "S", "N", "M", "B", "Z") && !m.contains(index-1, 1, "S", "K", "L"):
+		m.add("J")
+	}
+
+	return m.skipIf(index, 'J')
+}
+
+func (m *doubleMetaphone) handleL(index int) int {
+	if m.charAt(index+1) != 'L' {
+		m.add("L")
+		return index + 1
+	}
+
+	if m.conditionL0(index) {
+		m.addBoth("L", "")
+	} else {
+		m.add("L")
+	}
+	return index + 2
+}
+
+func (m *doubleMetaphone) handleP(index int) int {
+	if m.charAt(index+1) == 'H' {
+		m.add("F")
+		return index + 2
+	}
+
+	m.add("P")
+	if m.contains(index+1, 1, "P", "B") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleR(index int) int {
+	if index == m.length-1 && !m.slavoGermanic && m.contains(index-2, 2, "IE") && !m.contains(index-4, 2, "ME", "MA") {
+		m.addBoth("", "R")
+	} else {
+		m.add("R")
+	}
+	return m.skipIf(index, 'R')
+}
+
+func (m *doubleMetaphone) handleS(index int) int {
+	switch {
+	case m.contains(index-1, 3, "ISL", "YSL"):
+		// Silent S in words like "island", "isle" and "carlisle"
+		return index + 1
+	case index == 0 && m.contains(index, 5, "SUGAR"):
+		m.addBoth("X", "S")
+		return index + 1
+	case m.contains(index, 2, "SH"):
+		if m.contains(index+1, 4, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			m.add("S")
+		} else {
+			m.add("X")
+		}
+		return index + 2
+	case m.contains(index, 3, "SIO", "SIA") || m.contains(index, 4, "SIAN"):
+		if m.slavoGermanic {
+			m.add("S")
+		} else {
+			m.addBoth("S", "X")
+		}
+		return index + 3
+	case (index == 0 && m.contains(index+1, 1, "M", "N", "L", "W")) || m.contains(index+1, 1, "Z"):
+		m.addBoth("S", "X")
+		return m.skipIf(index, 'Z')
+	case m.contains(index, 2, "SC"):
+		return m.handleSC(index)
+	}
+
+	if index == m.length-1 && m.contains(index-2, 2, "AI", "OI") {
+		m.addBoth("", "S")
+	} else {
+		m.add("S")
+	}
+
+	if m.contains(index+1, 1, "S", "Z") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleSC(index int) int {
+	switch {
+	case m.charAt(index+2) == 'H':
+		switch {
+		case m.contains(index+3, 2, "ER", "EN"):
+			m.addBoth("X", "SK")
+		case m.contains(index+3, 2, "OO", "UY", "ED", "EM"):
+			m.add("SK")
+		case index == 0 && !m.isVowelAt(3) && m.charAt(3) != 'W':
+			m.addBoth("X", "S")
+		default:
+			m.add("X")
+		}
+	case m.contains(index+2, 1, "I", "E", "Y"):
+		m.add("S")
+	default:
+		m.add("SK")
+	}
+	return index + 3
+}
+
+func (m *doubleMetaphone) handleT(index int) int {
+	switch {
+	case m.contains(index, 4, "TION"):
+		m.add("X")
+		return index + 3
+	case m.contains(index, 3, "TIA", "TCH"):
+		m.add("X")
+		return index + 3
+	case m.contains(index, 2, "TH") || m.contains(index, 3, "TTH"):
+		if m.contains(index+2, 2, "OM", "AM") || m.contains(0, 4, "VAN ", "VON ") || m.contains(0, 3, "SCH") {
+			m.add("T")
+		} else {
+			m.addBoth("0", "T")
+		}
+		return index + 2
+	}
+
+	m.add("T")
+	if m.contains(index+1, 1, "T", "D") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleW(index int) int {
+	switch {
+	case m.contains(index, 2, "WR"):
+		m.add("R")
+		return index + 2
+	case index == 0 && (m.isVowelAt(index+1) || m.contains(index, 2, "WH")):
+		if m.isVowelAt(index + 1) {
+			m.addBoth("A", "F")
+		} else {
+			m.add("A")
+		}
+	case (index == m.length-1 && m.isVowelAt(index-1)) ||
+		m.contains(index-1, 5, "EWSKI", "EWSKY", "OWSKI", "OWSKY") ||
+		m.contains(0, 3, "SCH"):
+		m.addBoth("", "F")
+	case m.contains(index, 4, "WICZ", "WITZ"):
+		m.addBoth("TS", "FX")
+		return index + 4
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleX(index int) int {
+	if index == 0 {
+		m.add("S")
+		return index + 1
+	}
+
+	// French words ending in -IAUX, -EAUX, -AUX or -OUX have a silent X
+	if !(index == m.length-1 && (m.contains(index-3, 3, "IAU", "EAU") || m.contains(index-2, 2, "AU", "OU"))) {
+		m.add("KS")
+	}
+
+	if m.contains(index+1, 1, "C", "X") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) handleZ(index int) int {
+	if m.charAt(index+1) == 'H' {
+		m.add("J")
+		return index + 2
+	}
+
+	if m.contains(index+1, 2, "ZO", "ZI", "ZA") || (m.slavoGermanic && index > 0 && m.charAt(index-1) != 'T') {
+		m.addBoth("S", "TS")
+	} else {
+		m.add("S")
+	}
+	return m.skipIf(index, 'Z')
+}
+
+// conditionC0 matches the germanic "ACH" sound, as in "bacher" and "macher"
+func (m *doubleMetaphone) conditionC0(index int) bool {
+	switch {
+	case m.contains(index, 4, "CHIA"):
+		return true
+	case index <= 1, m.isVowelAt(index - 2), !m.contains(index-1, 3, "ACH"):
+		return false
+	}
+
+	c := m.charAt(index + 2)
+	return (c != 'I' && c != 'E') || m.contains(index-2, 6, "BACHER", "MACHER")
+}
+
+// conditionCH0 matches a greek initial CH, as in "character" and "chorus"
+func (m *doubleMetaphone) conditionCH0(index int) bool {
+	if index != 0 {
+		return false
+	}
+	if !m.contains(index+1, 5, "HARAC", "HARIS") && !m.contains(index+1, 3, "HOR", "HYM", "HIA", "HEM") {
+		return false
+	}
+	return !m.contains(0, 5, "CHORE")
+}
+
+// conditionCH1 matches germanic and greek CH sounds pronounced as K
+func (m *doubleMetaphone) conditionCH1(index int) bool {
+	return m.contains(0, 4, "VAN ", "VON ") || m.contains(0, 3, "SCH") ||
+		m.contains(index-2, 6, "ORCHES", "ARCHIT", "ORCHID") ||
+		m.contains(index+2, 1, "T", "S") ||
+		((m.contains(index-1, 1, "A", "O", "U", "E") || index == 0) &&
+			(m.contains(index+2, 1, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ") || index+1 == m.length-1))
+}
+
+// conditionL0 matches spanish double L, as in "cabrillo" and "gallegos"
+func (m *doubleMetaphone) conditionL0(index int) bool {
+	if index == m.length-3 && m.contains(index-1, 4, "ILLO", "ILLA", "ALLE") {
+		return true
+	}
+	return (m.contains(m.length-2, 2, "AS", "OS") || m.contains(m.length-1, 1, "A", "O")) &&
+		m.contains(index-1, 4, "ALLE")
+}
+
+// conditionM0 matches a double M or a silent B after M, as in "dumb" and "thumbelina"
+func (m *doubleMetaphone) conditionM0(index int) bool {
+	if m.charAt(index+1) == 'M' {
+		return true
+	}
+	return m.contains(index-1, 3, "UMB") && (index+1 == m.length-1 || m.contains(index+2, 2, "ER"))
+}
+
+func (m *doubleMetaphone) add(code string) {
+	m.addBoth(code, code)
+}
+
+func (m *doubleMetaphone) addBoth(primary, alternate string) {
+	appendLimited(&m.primary, primary)
+	appendLimited(&m.alternate, alternate)
+}
+
+func appendLimited(builder *strings.Builder, code string) {
+	remaining := doubleMetaphoneMaxLength - builder.Len()
+	if remaining <= 0 {
+		return
+	}
+	if len(code) > remaining {
+		code = code[:remaining]
+	}
+	builder.WriteString(code)
+}
+
+func (m *doubleMetaphone) complete() bool {
+	return m.primary.Len() >= doubleMetaphoneMaxLength && m.alternate.Len() >= doubleMetaphoneMaxLength
+}
+
+// skipIf skips the next letter when it repeats the given one
+func (m *doubleMetaphone) skipIf(index int, letter byte) int {
+	if m.charAt(index+1) == letter {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *doubleMetaphone) charAt(index int) byte {
+	if index < 0 || index >= m.length {
+		return 0
+	}
+	return m.value[index]
+}
+
+func (m *doubleMetaphone) isVowelAt(index int) bool {
+	c := m.charAt(index)
+	return c != 0 && strings.IndexByte("AEIOUY", c) >= 0
+}
+
+// contains reports whether the substring of the given length starting at index is one of the options
+func (m *doubleMetaphone) contains(index, length int, options ...string) bool {
+	if index < 0 || index+length > m.length {
+		return false
+	}
+
+	substring := m.value[index : index+length]
+	for _, option := range options {
+		if substring == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isSlavoGermanic(value string) bool {
+	return strings.ContainsAny(value, "WK") || strings.Contains(value, "CZ") || strings.Contains(value, "WITZ")
+}
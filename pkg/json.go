@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+)
+
+type JSONConnector interface {
+	ReadJSON(ctx context.Context, filePath string, value any) error
+	WriteJSON(ctx context.Context, filePath string, value any) error
+	Remove(ctx context.Context, filePath string) error
+	// AppendJSONLines keeps the first size bytes of the file and appends a line per value, returning the new size
+	AppendJSONLines(ctx context.Context, filePath string, size int64, values []any) (int64, error)
+	// ReadJSONLines yields the values of the first size bytes of the file, one per line
+	ReadJSONLines(ctx context.Context, filePath string, size int64) iter.Seq2[json.RawMessage, error]
+}
+
+type jsonConnector struct{}
+
+func NewJSONConnector() JSONConnector {
+	return &jsonConnector{}
+}
+
+// ReadJSON decodes the file into value, rejecting the fields value doesn't have, such as a misspelled option.
+// A missing file returns an error wrapping os.ErrNotExist
+func (jsonConnector) ReadJSON(ctx context.Context, filePath string, value any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(value); err != nil {
+		return fmt.Errorf("error reading JSON file: %w", err)
+	}
+
+	return nil
+}
+
+// WriteJSON encodes value to a temporary file next to the destination and renames it, so the file is never half written
+func (jsonConnector) WriteJSON(ctx context.Context, filePath string, value any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err = json.NewEncoder(file).Encode(value); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing JSON file: %w", err)
+	}
+
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	// os.CreateTemp creates the file only readable by its owner
+	if err = os.Chmod(file.Name(), 0o644); err != nil {
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	if err = os.Rename(file.Name(), filePath); err != nil {
+		return fmt.Errorf("error renaming file: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the file, a missing file is not an error
+func (jsonConnector) Remove(ctx context.Context, filePath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing file: %w", err)
+	}
+	return nil
+}
+
+// AppendJSONLines drops whatever follows the first size bytes, such as the lines of an append that was interrupted,
+// and then encodes every value on its own line. A missing file is created.
+func (jsonConnector) AppendJSONLines(ctx context.Context, filePath string, size int64, values []any) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+
+	if err = file.Truncate(size); err != nil {
+		return 0, fmt.Errorf("error truncating file: %w", err)
+	}
+	if _, err = file.Seek(size, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error truncating file: %w", err)
+	}
+
+	buffer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(buffer)
+	for _, value := range values {
+		if err = encoder.Encode(value); err != nil {
+			return 0, fmt.Errorf("error writing JSON file: %w", err)
+		}
+	}
+	if err = buffer.Flush(); err != nil {
+		return 0, fmt.Errorf("error writing JSON file: %w", err)
+	}
+
+	end, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("error writing JSON file: %w", err)
+	}
+
+	if err = file.Close(); err != nil {
+		return 0, fmt.Errorf("error closing file: %w", err)
+	}
+	return end, nil
+}
+
+func (jsonConnector) ReadJSONLines(ctx context.Context, filePath string, size int64) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		if err := ctx.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			yield(nil, fmt.Errorf("error opening file: %w", err))
+			return
+		}
+		defer file.Close()
+
+		decoder := json.NewDecoder(io.LimitReader(file, size))
+		for {
+			var value json.RawMessage
+			err = decoder.Decode(&value)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("error reading JSON file: %w", err))
+				return
+			}
+
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+)
+
+func streamJSONRecords(ctx context.Context, filePath string, format Format) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		file, err := os.Open(filePath)
+		if err != nil {
+			yield(nil, fmt.Errorf("error opening file: %w", err))
+			return
+		}
+		defer file.Close()
+
+		for record, err := range StreamJSONReader(ctx, file, format) {
+			if !yield(record, err) {
+				return
+			}
+		}
+	}
+}
+
+// StreamJSONReader yields the objects read from r as records: first a header with the sorted keys of every object,
+// then the values of every object for those keys. Missing keys and nulls are empty, an object with a nested value is
+// yielded as a *RecordError with a nil record and the stream goes on.
+// r is read twice, once for the keys and once for the values, so a key first seen on a later object isn't lost.
+// A JSON stream holds an array of objects, an NDJSON stream one object per line.
+func StreamJSONReader(ctx context.Context, r io.ReadSeeker, format Format) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		keys := make(map[string]bool)
+		objects := 0
+		for object, err := range decodeObjects(ctx, r, format) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			objects++
+			for key := range object {
+				keys[key] = true
+			}
+		}
+		if objects == 0 {
+			return
+		}
+
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			yield(nil, fmt.Errorf("error reading JSON file: %w", err))
+			return
+		}
+
+		header := slices.Sorted(maps.Keys(keys))
+		if !yield(header, nil) {
+			return
+		}
+
+		position := 0
+		for object, err := range decodeObjects(ctx, r, format) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			position++
+			record, err := objectRecord(header, object)
+			if err != nil {
+				err = &RecordError{Line: position, Err: err}
+			}
+
+			if !yield(record, err) {
+				return
+			}
+		}
+	}
+}
+
+// decodeObjects yields the objects of the JSON array or the NDJSON lines read from r
+func decodeObjects(ctx context.Context, r io.Reader, format Format) iter.Seq2[map[string]any, error] {
+	return func(yield func(map[string]any, error) bool) {
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+
+		if format == JSONFormat {
+			if err := readDelim(decoder, '['); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if format == JSONFormat && !decoder.More() {
+				break
+			}
+
+			var object map[string]any
+			err := decoder.Decode(&object)
+			if format == NDJSONFormat && errors.Is(err, io.EOF) {
+				return
+			}
+			if err == nil && object == nil {
+				err = errors.New("expected an object")
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("error reading JSON file: %w", err))
+				return
+			}
+
+			if !yield(object, nil) {
+				return
+			}
+		}
+
+		if err := readDelim(decoder, ']'); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func readDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("error reading JSON file: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("error reading JSON file: expected %v, found %v", delim, token)
+	}
+	return nil
+}
+
+// objectRecord lists the values of the object in header order, only strings, numbers, booleans and nulls are values
+func objectRecord(header []string, object map[string]any) ([]string, error) {
+	record := make([]string, len(header))
+	for i, key := range header {
+		switch value := object[key].(type) {
+		case nil:
+		case string:
+			record[i] = value
+		case json.Number:
+			record[i] = value.String()
+		case bool:
+			record[i] = strconv.FormatBool(value)
+		default:
+			return nil, fmt.Errorf("unsupported value of key %q", key)
+		}
+	}
+	return record, nil
+}
+
+type jsonWriter struct {
+	ctx      context.Context
+	file     *os.File
+	writer   *bufio.Writer
+	filePath string
+	format   Format
+	records  int
+}
+
+// newJSONWriter creates a temporary file next to the destination, the values are encoded with their json tags
+func newJSONWriter(ctx context.Context, filePath string, format Format) (RecordWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	file, err := createTempFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonWriter{
+		ctx:      ctx,
+		file:     file,
+		writer:   bufio.NewWriter(file),
+		filePath: filePath,
+		format:   format,
+	}, nil
+}
+
+// Write encodes the value on its own line, JSON arrays separate the values with commas
+func (w *jsonWriter) Write(_ []string, value any) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("error writing record: %w", err)
+	}
+
+	if w.format == JSONFormat {
+		separator := ",\n"
+		if w.records == 0 {
+			separator = "[\n"
+		}
+		w.writer.WriteString(separator)
+		encoded.Truncate(encoded.Len() - 1)
+	}
+
+	w.records++
+	if _, err := w.writer.Write(encoded.Bytes()); err != nil {
+		return fmt.Errorf("error writing record: %w", err)
+	}
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		w.Abort()
+		return err
+	}
+
+	if w.format == JSONFormat {
+		end := "\n]\n"
+		if w.records == 0 {
+			end = "[]\n"
+		}
+		w.writer.WriteString(end)
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		w.Abort()
+		return fmt.Errorf("error writing record: %w", err)
+	}
+
+	return commitFile(w.file, w.filePath)
+}
+
+func (w *jsonWriter) Abort() error {
+	w.file.Close()
+	if err := os.Remove(w.file.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing temporary file: %w", err)
+	}
+	return nil
+}
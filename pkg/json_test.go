@@ -0,0 +1,128 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestJSONConnector(t *testing.T) {
+	connector := pkg.NewJSONConnector()
+	ctx := context.Background()
+
+	t.Run("when writing a value, it should read it back", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "value.json")
+
+		err := connector.WriteJSON(ctx, filePath, jsonValue{Name: "pairs", Count: 3})
+		assert.Nil(t, err)
+
+		var value jsonValue
+		err = connector.ReadJSON(ctx, filePath, &value)
+		assert.Nil(t, err)
+		assert.Equal(t, jsonValue{Name: "pairs", Count: 3}, value)
+
+		entries, err := os.ReadDir(filepath.Dir(filePath))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(entries))
+	})
+
+	t.Run("when the file does not exist, it should return a not exist error", func(t *testing.T) {
+		var value jsonValue
+		err := connector.ReadJSON(ctx, filepath.Join(t.TempDir(), "missing.json"), &value)
+
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("when the file is not valid JSON, it should return an error", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "value.json")
+		assert.Nil(t, os.WriteFile(filePath, []byte("{"), 0o644))
+
+		var value jsonValue
+		err := connector.ReadJSON(ctx, filePath, &value)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "error reading JSON file")
+	})
+
+	t.Run("when the file has a field the value doesn't have, it should return an error", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "value.json")
+		assert.Nil(t, os.WriteFile(filePath, []byte(`{"name":"pairs","size":3}`), 0o644))
+
+		var value jsonValue
+		err := connector.ReadJSON(ctx, filePath, &value)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), `unknown field "size"`)
+	})
+
+	t.Run("when appending lines, it should drop what follows the given size and read the lines back", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "values.ndjson")
+
+		size, err := connector.AppendJSONLines(ctx, filePath, 0, []any{jsonValue{Name: "first", Count: 1}})
+		assert.Nil(t, err)
+		_, err = connector.AppendJSONLines(ctx, filePath, size, []any{jsonValue{Name: "interrupted", Count: 2}})
+		assert.Nil(t, err)
+		size, err = connector.AppendJSONLines(ctx, filePath, size, []any{jsonValue{Name: "second", Count: 3}})
+		assert.Nil(t, err)
+
+		var values []jsonValue
+		for line, err := range connector.ReadJSONLines(ctx, filePath, size) {
+			assert.Nil(t, err)
+			var value jsonValue
+			assert.Nil(t, json.Unmarshal(line, &value))
+			values = append(values, value)
+		}
+		assert.Equal(t, []jsonValue{{Name: "first", Count: 1}, {Name: "second", Count: 3}}, values)
+	})
+
+	t.Run("when reading lines, it should only read the first size bytes", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "values.ndjson")
+		assert.Nil(t, os.WriteFile(filePath, []byte("{\"name\":\"first\"}\n{\"name\":"), 0o644))
+
+		var lines []string
+		for line, err := range connector.ReadJSONLines(ctx, filePath, 17) {
+			assert.Nil(t, err)
+			lines = append(lines, string(line))
+		}
+		assert.Equal(t, []string{`{"name":"first"}`}, lines)
+	})
+
+	t.Run("when removing a file, it should not fail if it is already missing", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "value.json")
+		assert.Nil(t, connector.WriteJSON(ctx, filePath, jsonValue{}))
+
+		assert.Nil(t, connector.Remove(ctx, filePath))
+		assert.NoFileExists(t, filePath)
+		assert.Nil(t, connector.Remove(ctx, filePath))
+	})
+}
+
+func TestFileChecksum(t *testing.T) {
+	t.Run("when reading a file, it should return the SHA-256 of its contents", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "input.csv")
+		assert.Nil(t, os.WriteFile(filePath, []byte("abc"), 0o644))
+
+		checksum, err := pkg.FileChecksum(context.Background(), filePath)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", checksum)
+	})
+
+	t.Run("when the file does not exist, it should return an error", func(t *testing.T) {
+		_, err := pkg.FileChecksum(context.Background(), "non_existent_file.csv")
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "error opening file")
+	})
+}
@@ -0,0 +1,199 @@
+package pkg
+
+import "strings"
+
+var soundexCodes = map[rune]byte{
+	'B': '1', 'F': '1', 'P': '1', 'V': '1',
+	'C': '2', 'G': '2', 'J': '2', 'K': '2', 'Q': '2', 'S': '2', 'X': '2', 'Z': '2',
+	'D': '3', 'T': '3',
+	'L': '4',
+	'M': '5', 'N': '5',
+	'R': '6',
+}
+
+// Soundex returns the American Soundex code of the given word, e.g. "Robert" -> "R163".
+// Non-letter characters are ignored and an empty string is returned when the word has no letters.
+func Soundex(word string) string {
+	var letters []rune
+	for _, r := range strings.ToUpper(word) {
+		if r >= 'A' && r <= 'Z' {
+			letters = append(letters, r)
+		}
+	}
+
+	if len(letters) == 0 {
+		return ""
+	}
+
+	code := []byte{byte(letters[0])}
+	lastCode := soundexCodes[letters[0]]
+
+	for _, r := range letters[1:] {
+		if len(code) == 4 {
+			break
+		}
+
+		digit, ok := soundexCodes[r]
+		switch {
+		case ok && digit != lastCode:
+			code = append(code, digit)
+			lastCode = digit
+		case !ok && r != 'H' && r != 'W':
+			// Vowels separate letters with the same code, H and W do not
+			lastCode = 0
+		}
+	}
+
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
+
+// Metaphone returns the original Metaphone key of the given word, e.g. "Catherine" -> "K0RN".
+// The letter 0 stands for the "th" sound and X for the "sh" sound.
+func Metaphone(word string) string {
+	var letters []byte
+	for _, r := range strings.ToUpper(word) {
+		if r >= 'A' && r <= 'Z' {
+			letters = append(letters, byte(r))
+		}
+	}
+
+	if len(letters) == 0 {
+		return ""
+	}
+
+	// Drop duplicate adjacent letters, except for C
+	value := letters[:1]
+	for _, letter := range letters[1:] {
+		if letter != value[len(value)-1] || letter == 'C' {
+			value = append(value, letter)
+		}
+	}
+
+	// Initial letter exceptions
+	switch {
+	case hasPrefix(value, "AE", "GN", "KN", "PN", "WR"):
+		value = value[1:]
+	case value[0] == 'X':
+		value[0] = 'S'
+	case hasPrefix(value, "WH"):
+		value = append([]byte{'W'}, value[2:]...)
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(value) {
+			return 0
+		}
+		return value[i]
+	}
+
+	var key strings.Builder
+	for i, letter := range value {
+		previous, next := at(i-1), at(i+1)
+
+		switch letter {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				key.WriteByte(letter)
+			}
+		case 'B':
+			if !(previous == 'M' && i == len(value)-1) {
+				key.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case next == 'I' && at(i+2) == 'A', next == 'H' && previous != 'S':
+				key.WriteByte('X')
+			case next == 'I' || next == 'E' || next == 'Y':
+				if previous != 'S' {
+					key.WriteByte('S')
+				}
+			default:
+				key.WriteByte('K')
+			}
+		case 'D':
+			if next == 'G' && isFrontVowel(at(i+2)) {
+				key.WriteByte('J')
+			} else {
+				key.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case next == 'H' && i+2 < len(value) && !isVowel(at(i+2)):
+			case next == 'N' && (i+2 == len(value) || string(value[i+1:]) == "NED"):
+			case previous == 'D' && isFrontVowel(next):
+			case isFrontVowel(next):
+				key.WriteByte('J')
+			default:
+				key.WriteByte('K')
+			}
+		case 'H':
+			if isVowel(next) && !strings.ContainsRune("CGPST", rune(previous)) {
+				key.WriteByte('H')
+			}
+		case 'K':
+			if previous != 'C' {
+				key.WriteByte('K')
+			}
+		case 'P':
+			if next == 'H' {
+				key.WriteByte('F')
+			} else {
+				key.WriteByte('P')
+			}
+		case 'Q':
+			key.WriteByte('K')
+		case 'S':
+			switch {
+			case next == 'H', next == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				key.WriteByte('X')
+			default:
+				key.WriteByte('S')
+			}
+		case 'T':
+			switch {
+			case next == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				key.WriteByte('X')
+			case next == 'H':
+				key.WriteByte('0')
+			case next == 'C' && at(i+2) == 'H':
+			default:
+				key.WriteByte('T')
+			}
+		case 'V':
+			key.WriteByte('F')
+		case 'W', 'Y':
+			if isVowel(next) {
+				key.WriteByte(letter)
+			}
+		case 'X':
+			key.WriteString("KS")
+		case 'Z':
+			key.WriteByte('S')
+		default:
+			key.WriteByte(letter)
+		}
+	}
+
+	return key.String()
+}
+
+func hasPrefix(value []byte, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(string(value), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVowel(letter byte) bool {
+	return letter != 0 && strings.IndexByte("AEIOU", letter) >= 0
+}
+
+func isFrontVowel(letter byte) bool {
+	return letter != 0 && strings.IndexByte("EIY", letter) >= 0
+}
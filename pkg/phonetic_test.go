@@ -0,0 +1,72 @@
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoundex(t *testing.T) {
+	t.Run("when encoding names, it should return the american soundex code", func(t *testing.T) {
+		assert.Equal(t, "R163", pkg.Soundex("Robert"))
+		assert.Equal(t, "R163", pkg.Soundex("Rupert"))
+		assert.Equal(t, "A261", pkg.Soundex("Ashcraft"))
+		assert.Equal(t, "T522", pkg.Soundex("Tymczak"))
+		assert.Equal(t, "P236", pkg.Soundex("Pfister"))
+		assert.Equal(t, "L000", pkg.Soundex("Lee"))
+	})
+
+	t.Run("when the word has no letters, it should return an empty code", func(t *testing.T) {
+		assert.Equal(t, "", pkg.Soundex(""))
+		assert.Equal(t, "", pkg.Soundex("123"))
+	})
+}
+
+func TestMetaphone(t *testing.T) {
+	t.Run("when encoding names that sound alike, it should return the same key", func(t *testing.T) {
+		assert.Equal(t, "K0RN", pkg.Metaphone("Catherine"))
+		assert.Equal(t, "K0RN", pkg.Metaphone("Kathryn"))
+		assert.Equal(t, "STFN", pkg.Metaphone("Stephen"))
+		assert.Equal(t, "STFN", pkg.Metaphone("Steven"))
+	})
+
+	t.Run("when the word starts with a silent letter, it should skip it", func(t *testing.T) {
+		assert.Equal(t, "NT", pkg.Metaphone("Knight"))
+		assert.Equal(t, "RT", pkg.Metaphone("Wright"))
+	})
+
+	t.Run("when the word has no letters, it should return an empty key", func(t *testing.T) {
+		assert.Equal(t, "", pkg.Metaphone(""))
+	})
+}
+
+func TestDoubleMetaphone(t *testing.T) {
+	t.Run("when encoding names, it should return the primary and alternate keys", func(t *testing.T) {
+		testCases := []struct {
+			word      string
+			primary   string
+			alternate string
+		}{
+			{word: "Schmidt", primary: "XMT", alternate: "SMT"},
+			{word: "Smith", primary: "SM0", alternate: "XMT"},
+			{word: "Catherine", primary: "K0RN", alternate: "KTRN"},
+			{word: "Kathryn", primary: "K0RN", alternate: "KTRN"},
+			{word: "Jose", primary: "HS", alternate: "HS"},
+			{word: "Gallegos", primary: "KLKS", alternate: "KKS"},
+			{word: "Xavier", primary: "SF", alternate: "SFR"},
+		}
+
+		for _, testCase := range testCases {
+			primary, alternate := pkg.DoubleMetaphone(testCase.word)
+			assert.Equal(t, testCase.primary, primary, testCase.word)
+			assert.Equal(t, testCase.alternate, alternate, testCase.word)
+		}
+	})
+
+	t.Run("when the word is empty, it should return empty keys", func(t *testing.T) {
+		primary, alternate := pkg.DoubleMetaphone("  ")
+		assert.Equal(t, "", primary)
+		assert.Equal(t, "", alternate)
+	})
+}
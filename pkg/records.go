@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	UnsupportedFormatError = "unsupported format, use csv, json or ndjson"
+)
+
+// Format is the encoding of a file of records
+type Format string
+
+const (
+	CSVFormat Format = "csv"
+	// JSONFormat is a JSON array with an object per record
+	JSONFormat Format = "json"
+	// NDJSONFormat is newline-delimited JSON, an object per line
+	NDJSONFormat Format = "ndjson"
+)
+
+// ParseFormat validates the name of a format
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case CSVFormat, JSONFormat, NDJSONFormat:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%s: %q", UnsupportedFormatError, name)
+	}
+}
+
+// FormatOf tells the format of a file by its extension, files that are not JSON nor NDJSON are CSV
+func FormatOf(filePath string) Format {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return JSONFormat
+	case ".ndjson", ".jsonl":
+		return NDJSONFormat
+	default:
+		return CSVFormat
+	}
+}
+
+// Extension is the file extension of the format, dot included
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// RecordError is a record that could not be read, the stream goes on with the next record.
+// Record holds its fields when they were read, such as a CSV record with a different number of fields than the header.
+type RecordError struct {
+	// Line is the line of a CSV file the record starts on, the header is line 1, or the position of a JSON object
+	Line   int
+	Record []string
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("error reading record %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// RecordConnector reads and writes files of records whatever their format
+type RecordConnector interface {
+	// StreamRecords yields the header and then every record, the same way as CSVConnector.StreamCSV
+	StreamRecords(ctx context.Context, filePath string) iter.Seq2[[]string, error]
+	NewRecordWriter(ctx context.Context, filePath string, header []string) (RecordWriter, error)
+}
+
+// RecordWriter writes the records one at a time to a temporary file.
+// Close replaces the destination file with it, Abort discards it leaving the destination untouched.
+type RecordWriter interface {
+	// Write adds a record, CSV files write its fields in header order while JSON files encode its value
+	Write(fields []string, value any) error
+	Close() error
+	Abort() error
+}
+
+type recordConnector struct {
+	csv CSVConnector
+}
+
+// NewRecordConnector reads and writes every file in the format of its extension, see FormatOf. CSV files go through csv.
+func NewRecordConnector(csv CSVConnector) RecordConnector {
+	return &recordConnector{csv: csv}
+}
+
+func (r recordConnector) StreamRecords(ctx context.Context, filePath string) iter.Seq2[[]string, error] {
+	if format := FormatOf(filePath); format != CSVFormat {
+		return streamJSONRecords(ctx, filePath, format)
+	}
+	return r.csv.StreamCSV(ctx, filePath)
+}
+
+func (r recordConnector) NewRecordWriter(ctx context.Context, filePath string, header []string) (RecordWriter, error) {
+	if format := FormatOf(filePath); format != CSVFormat {
+		return newJSONWriter(ctx, filePath, format)
+	}
+
+	writer, err := r.csv.NewCSVWriter(ctx, filePath, header)
+	if err != nil {
+		return nil, err
+	}
+	return csvRecordWriter{writer}, nil
+}
+
+// csvRecordWriter writes the fields of every record
+type csvRecordWriter struct {
+	CSVWriter
+}
+
+func (w csvRecordWriter) Write(fields []string, _ any) error {
+	return w.CSVWriter.Write(fields)
+}
+
+// createTempFile creates the temporary file next to the destination that is renamed to it by commitFile
+func createTempFile(filePath string) (*os.File, error) {
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %w", err)
+	}
+	return file, nil
+}
+
+// commitFile closes the temporary file and replaces the destination with it, removing it when that fails
+func commitFile(file *os.File, filePath string) error {
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	// os.CreateTemp creates the file only readable by its owner
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), filePath); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("error renaming file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"`
+}
+
+func TestFormat(t *testing.T) {
+	t.Run("when the file has a JSON or NDJSON extension, it should be read in that format", func(t *testing.T) {
+		assert.Equal(t, pkg.JSONFormat, pkg.FormatOf("files/input.json"))
+		assert.Equal(t, pkg.NDJSONFormat, pkg.FormatOf("files/input.ndjson"))
+		assert.Equal(t, pkg.NDJSONFormat, pkg.FormatOf("files/input.JSONL"))
+		assert.Equal(t, pkg.CSVFormat, pkg.FormatOf("files/input.csv"))
+		assert.Equal(t, pkg.CSVFormat, pkg.FormatOf("files/input"))
+	})
+
+	t.Run("when parsing a format name, it should only accept the supported formats", func(t *testing.T) {
+		format, err := pkg.ParseFormat("NDJSON")
+		assert.Nil(t, err)
+		assert.Equal(t, pkg.NDJSONFormat, format)
+		assert.Equal(t, ".ndjson", format.Extension())
+
+		_, err = pkg.ParseFormat("xml")
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), pkg.UnsupportedFormatError)
+	})
+}
+
+func TestStreamJSONReader(t *testing.T) {
+	ctx := context.Background()
+
+	// collect gathers the records of the stream and its error
+	collect := func(content string, format pkg.Format) ([][]string, error) {
+		var records [][]string
+		for record, err := range pkg.StreamJSONReader(ctx, strings.NewReader(content), format) {
+			if err != nil {
+				return records, err
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	}
+
+	t.Run("when reading a JSON array, it should yield the sorted keys of every object and every value", func(t *testing.T) {
+		records, err := collect(`[{"name":"John","id":1,"active":true},{"id":2,"name":null,"other":"x"}]`, pkg.JSONFormat)
+
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"active", "id", "name", "other"}, {"true", "1", "John", ""}, {"", "2", "", "x"}}, records)
+	})
+
+	t.Run("when reading NDJSON, it should yield an object per line", func(t *testing.T) {
+		records, err := collect("{\"id\":\"1\"}\n\n{\"id\":\"2\"}\n", pkg.NDJSONFormat)
+
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"id"}, {"1"}, {"2"}}, records)
+	})
+
+	t.Run("when the JSON array is empty, it should yield no records", func(t *testing.T) {
+		records, err := collect(`[]`, pkg.JSONFormat)
+
+		assert.Nil(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("when a value is nested, it should yield a record error and go on", func(t *testing.T) {
+		var records [][]string
+		var errs []error
+		for record, err := range pkg.StreamJSONReader(ctx, strings.NewReader(`[{"id":"1","tags":["a"]},{"id":"2"}]`), pkg.JSONFormat) {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			records = append(records, record)
+		}
+
+		assert.Equal(t, [][]string{{"id", "tags"}, {"2", ""}}, records)
+		assert.Equal(t, 1, len(errs))
+		var recordErr *pkg.RecordError
+		assert.True(t, errors.As(errs[0], &recordErr))
+		assert.Equal(t, 1, recordErr.Line)
+		assert.Contains(t, recordErr.Error(), `unsupported value of key "tags"`)
+	})
+
+	t.Run("when the JSON file is not an array of objects, it should yield an error", func(t *testing.T) {
+		_, err := collect(`{"id":"1"}`, pkg.JSONFormat)
+		assert.NotNil(t, err)
+
+		_, err = collect(`["1"]`, pkg.JSONFormat)
+		assert.NotNil(t, err)
+
+		_, err = collect("null\n", pkg.NDJSONFormat)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRecordConnector(t *testing.T) {
+	connector := pkg.NewRecordConnector(pkg.NewCSVConnector())
+	ctx := context.Background()
+	values := []recordValue{{Name: "John", Count: 2}, {Name: "A & B"}}
+
+	// write writes the values to the file, with their name and count as CSV fields
+	write := func(t *testing.T, filePath string, values []recordValue) {
+		writer, err := connector.NewRecordWriter(ctx, filePath, []string{"name", "count"})
+		assert.Nil(t, err)
+		for _, value := range values {
+			assert.Nil(t, writer.Write([]string{value.Name, "count"}, value))
+		}
+		assert.Nil(t, writer.Close())
+	}
+
+	t.Run("when writing a JSON file, it should write an array with an object per line", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.json")
+
+		write(t, filePath, values)
+
+		content, err := os.ReadFile(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, "[\n{\"name\":\"John\",\"count\":2},\n{\"name\":\"A & B\"}\n]\n", string(content))
+	})
+
+	t.Run("when writing an empty JSON file, it should write an empty array", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.json")
+
+		write(t, filePath, nil)
+
+		content, err := os.ReadFile(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, "[]\n", string(content))
+	})
+
+	t.Run("when writing an NDJSON file, it should read the values back", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.ndjson")
+
+		write(t, filePath, values)
+
+		content, err := os.ReadFile(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, "{\"name\":\"John\",\"count\":2}\n{\"name\":\"A & B\"}\n", string(content))
+
+		var records [][]string
+		for record, err := range connector.StreamRecords(ctx, filePath) {
+			assert.Nil(t, err)
+			records = append(records, record)
+		}
+		assert.Equal(t, [][]string{{"count", "name"}, {"2", "John"}, {"", "A & B"}}, records)
+	})
+
+	t.Run("when writing a CSV file, it should write the fields after the header", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.csv")
+
+		write(t, filePath, values[:1])
+
+		assert.Equal(t, [][]string{{"name", "count"}, {"John", "count"}}, readCSVFile(t, filePath))
+	})
+
+	t.Run("when the writer is aborted, it should leave the existing file untouched", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "output.ndjson")
+		assert.Nil(t, os.WriteFile(filePath, []byte("{}\n"), 0o644))
+
+		writer, err := connector.NewRecordWriter(ctx, filePath, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, writer.Write(nil, values[0]))
+		assert.Nil(t, writer.Abort())
+
+		content, err := os.ReadFile(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, "{}\n", string(content))
+		entries, err := os.ReadDir(filepath.Dir(filePath))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(entries))
+	})
+
+	t.Run("when the JSON file does not exist, it should yield an error", func(t *testing.T) {
+		var errs []error
+		for _, err := range connector.StreamRecords(ctx, "non_existent_file.json") {
+			errs = append(errs, err)
+		}
+
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "error opening file")
+	})
+}
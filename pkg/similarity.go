@@ -0,0 +1,204 @@
+package pkg
+
+import (
+	"sort"
+	"strings"
+)
+
+// Levenshtein returns the minimum number of insertions, deletions and substitutions needed to turn a into b
+func Levenshtein(a, b string) int {
+	r1, r2 := []rune(a), []rune(b)
+	previous := make([]int, len(r2)+1)
+	current := make([]int, len(r2)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(r1); i++ {
+		current[0] = i
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(r2)]
+}
+
+// DamerauLevenshtein returns the Levenshtein distance also counting the transposition of two adjacent
+// characters as a single edit. It uses the optimal string alignment variant, so a substring is never edited twice.
+func DamerauLevenshtein(a, b string) int {
+	r1, r2 := []rune(a), []rune(b)
+	// Only the last three rows of the distance matrix are needed
+	beforePrevious := make([]int, len(r2)+1)
+	previous := make([]int, len(r2)+1)
+	current := make([]int, len(r2)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(r1); i++ {
+		current[0] = i
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && r1[i-1] == r2[j-2] && r1[i-2] == r2[j-1] {
+				current[j] = min(current[j], beforePrevious[j-2]+1)
+			}
+		}
+		beforePrevious, previous, current = previous, current, beforePrevious
+	}
+
+	return previous[len(r2)]
+}
+
+// LevenshteinSimilarity normalizes the Levenshtein distance to a 0-1 similarity
+func LevenshteinSimilarity(a, b string) float64 {
+	return distanceToSimilarity(Levenshtein(a, b), a, b)
+}
+
+// DamerauLevenshteinSimilarity normalizes the Damerau-Levenshtein distance to a 0-1 similarity
+func DamerauLevenshteinSimilarity(a, b string) float64 {
+	return distanceToSimilarity(DamerauLevenshtein(a, b), a, b)
+}
+
+func distanceToSimilarity(distance int, a, b string) float64 {
+	maxLength := max(len([]rune(a)), len([]rune(b)))
+	if maxLength == 0 {
+		return 1
+	}
+	return 1 - float64(distance)/float64(maxLength)
+}
+
+// Jaro returns the Jaro similarity of two strings, between 0 and 1
+func Jaro(a, b string) float64 {
+	r1, r2 := []rune(a), []rune(b)
+	if len(r1) == 0 && len(r2) == 0 {
+		return 1
+	}
+	if len(r1) == 0 || len(r2) == 0 {
+		return 0
+	}
+
+	matchDistance := max(len(r1), len(r2))/2 - 1
+	if matchDistance < 0 {
+		matchDistance = 0
+	}
+
+	matched1 := make([]bool, len(r1))
+	matched2 := make([]bool, len(r2))
+	var matches int
+
+	for i := range r1 {
+		start := max(0, i-matchDistance)
+		end := min(len(r2), i+matchDistance+1)
+		for j := start; j < end; j++ {
+			if matched2[j] || r1[i] != r2[j] {
+				continue
+			}
+			matched1[i] = true
+			matched2[j] = true
+			matches++
+			break
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	var transpositions, k int
+	for i := range r1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[k] {
+			k++
+		}
+		if r1[i] != r2[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(r1)) + m/float64(len(r2)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// JaroWinkler boosts the Jaro similarity of strings sharing a common prefix of up to four characters
+func JaroWinkler(a, b string) float64 {
+	const (
+		prefixScale     = 0.1
+		maxPrefixLength = 4
+	)
+
+	similarity := Jaro(a, b)
+	r1, r2 := []rune(a), []rune(b)
+
+	var prefix int
+	for prefix < min(len(r1), len(r2), maxPrefixLength) && r1[prefix] == r2[prefix] {
+		prefix++
+	}
+
+	return similarity + float64(prefix)*prefixScale*(1-similarity)
+}
+
+// TokenSetRatio compares the sets of words of both strings, ignoring order and repeated words.
+// The shared words are compared against each side's remaining words and the best Levenshtein similarity is returned.
+// A string whose words are all found in the other one is a full match on purpose, so "Oak Avenue" matches
+// "Apartment 4 Oak Avenue", while a string without words only matches another one.
+func TokenSetRatio(a, b string) float64 {
+	tokens1 := tokenSet(a)
+	tokens2 := tokenSet(b)
+	if len(tokens1) == 0 || len(tokens2) == 0 {
+		if len(tokens1) == len(tokens2) {
+			return 1
+		}
+		return 0
+	}
+
+	var intersection, diff1, diff2 []string
+	for token := range tokens1 {
+		if tokens2[token] {
+			intersection = append(intersection, token)
+		} else {
+			diff1 = append(diff1, token)
+		}
+	}
+	for token := range tokens2 {
+		if !tokens1[token] {
+			diff2 = append(diff2, token)
+		}
+	}
+
+	sort.Strings(intersection)
+	sort.Strings(diff1)
+	sort.Strings(diff2)
+
+	sorted := strings.Join(intersection, " ")
+	combined1 := strings.TrimSpace(sorted + " " + strings.Join(diff1, " "))
+	combined2 := strings.TrimSpace(sorted + " " + strings.Join(diff2, " "))
+
+	return max(
+		LevenshteinSimilarity(sorted, combined1),
+		LevenshteinSimilarity(sorted, combined2),
+		LevenshteinSimilarity(combined1, combined2),
+	)
+}
+
+func tokenSet(value string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, token := range strings.Fields(strings.ToLower(value)) {
+		tokens[token] = true
+	}
+	return tokens
+}
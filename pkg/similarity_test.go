@@ -0,0 +1,81 @@
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/sebastianreh/compass-code-assessment/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	t.Run("when comparing strings, it should return the edit distance", func(t *testing.T) {
+		assert.Equal(t, 3, pkg.Levenshtein("kitten", "sitting"))
+		assert.Equal(t, 1, pkg.Levenshtein("Jon", "John"))
+		assert.Equal(t, 2, pkg.Levenshtein("ca", "ac"))
+		assert.Equal(t, 4, pkg.Levenshtein("", "john"))
+	})
+
+	t.Run("when normalizing the distance, it should return a similarity between 0 and 1", func(t *testing.T) {
+		assert.Equal(t, 0.75, pkg.LevenshteinSimilarity("Jon", "John"))
+		assert.Equal(t, 1.0, pkg.LevenshteinSimilarity("", ""))
+		assert.Equal(t, 0.0, pkg.LevenshteinSimilarity("abc", "xyz"))
+	})
+}
+
+func TestDamerauLevenshtein(t *testing.T) {
+	t.Run("when adjacent characters are transposed, it should count a single edit", func(t *testing.T) {
+		assert.Equal(t, 1, pkg.DamerauLevenshtein("ca", "ac"))
+		assert.Equal(t, 1, pkg.DamerauLevenshtein("john@mail.com", "jhon@mail.com"))
+		assert.Equal(t, 3, pkg.DamerauLevenshtein("kitten", "sitting"))
+		assert.Equal(t, 0.5, pkg.DamerauLevenshteinSimilarity("abcd", "badc"))
+	})
+}
+
+func TestJaroWinkler(t *testing.T) {
+	t.Run("when comparing strings, it should return the jaro and jaro-winkler similarities", func(t *testing.T) {
+		assert.InDelta(t, 0.944, pkg.Jaro("MARTHA", "MARHTA"), 0.001)
+		assert.InDelta(t, 0.961, pkg.JaroWinkler("MARTHA", "MARHTA"), 0.001)
+		assert.InDelta(t, 0.840, pkg.JaroWinkler("DWAYNE", "DUANE"), 0.001)
+		assert.InDelta(t, 0.813, pkg.JaroWinkler("DIXON", "DICKSONX"), 0.001)
+	})
+
+	t.Run("when a string is empty, it should only match another empty string", func(t *testing.T) {
+		assert.Equal(t, 1.0, pkg.JaroWinkler("", ""))
+		assert.Equal(t, 0.0, pkg.JaroWinkler("john", ""))
+	})
+}
+
+func TestTokenSetRatio(t *testing.T) {
+	t.Run("when the words are reordered or repeated, it should return a full match", func(t *testing.T) {
+		assert.Equal(t, 1.0, pkg.TokenSetRatio("Main Street 123", "123 main street"))
+		assert.Equal(t, 1.0, pkg.TokenSetRatio("Main Main Street", "Street Main"))
+	})
+
+	t.Run("when a string has extra words, it should still return a full match", func(t *testing.T) {
+		assert.Equal(t, 1.0, pkg.TokenSetRatio("Oak Avenue", "Apartment 4 Oak Avenue"))
+		assert.Equal(t, 1.0, pkg.TokenSetRatio("Main", "123 Main St"))
+	})
+
+	t.Run("when a string has no words, it should only match another string without words", func(t *testing.T) {
+		testCases := []struct {
+			a, b     string
+			expected float64
+		}{
+			{a: "", b: "123 Main St", expected: 0},
+			{a: "   ", b: "123 Main St", expected: 0},
+			{a: "123 Main St", b: "\t", expected: 0},
+			{a: "", b: "", expected: 1},
+			{a: " ", b: "", expected: 1},
+		}
+
+		for _, testCase := range testCases {
+			assert.Equal(t, testCase.expected, pkg.TokenSetRatio(testCase.a, testCase.b), "%q and %q", testCase.a, testCase.b)
+		}
+	})
+
+	t.Run("when the words are different, it should return a partial similarity", func(t *testing.T) {
+		similarity := pkg.TokenSetRatio("123 Main Street", "123 Main Road")
+		assert.Greater(t, similarity, 0.0)
+		assert.Less(t, similarity, 1.0)
+	})
+}